@@ -0,0 +1,85 @@
+/*
+Copyright 2020 qingmu.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// mergeEnv returns the application env followed by the defaults it does not set. The application
+// env keeps its order, so $(VAR) references keep resolving, the defaults are sorted by name.
+// Among the same name the last application env wins, at the position of the first one
+func mergeEnv(env []v1.EnvVar, defaults []v1.EnvVar) []v1.EnvVar {
+	merged := dedupEnv(env)
+	set := make(map[string]bool, len(merged))
+	for _, e := range merged {
+		set[e.Name] = true
+	}
+	appended := make([]v1.EnvVar, 0, len(defaults))
+	for _, e := range overrideEnv(nil, defaults) {
+		if !set[e.Name] {
+			appended = append(appended, e)
+		}
+	}
+	return append(merged, appended...)
+}
+
+// overrideEnv returns the env of base overridden by name with the env of override, sorted by name
+func overrideEnv(base []v1.EnvVar, override []v1.EnvVar) []v1.EnvVar {
+	if base == nil && override == nil {
+		return nil
+	}
+	env := dedupEnv(append(append([]v1.EnvVar{}, base...), override...))
+	sort.SliceStable(env, func(i, j int) bool { return env[i].Name < env[j].Name })
+	return env
+}
+
+// dedupEnv removes the duplicated names, the last one wins at the position of the first one
+func dedupEnv(env []v1.EnvVar) []v1.EnvVar {
+	index := make(map[string]int, len(env))
+	deduped := make([]v1.EnvVar, 0, len(env))
+	for _, e := range env {
+		if i, ok := index[e.Name]; ok {
+			deduped[i] = e
+			continue
+		}
+		index[e.Name] = len(deduped)
+		deduped = append(deduped, e)
+	}
+	return deduped
+}
+
+// mergePullSecrets returns the pull secrets of the application and the defaults, sorted and de-duplicated
+func mergePullSecrets(secrets []string, defaults []string) []string {
+	if len(secrets) == 0 && len(defaults) == 0 {
+		return secrets
+	}
+	set := make(map[string]bool, len(secrets)+len(defaults))
+	merged := make([]string, 0, len(secrets)+len(defaults))
+	for _, list := range [][]string{secrets, defaults} {
+		for _, secret := range list {
+			if secret != "" && !set[secret] {
+				set[secret] = true
+				merged = append(merged, secret)
+			}
+		}
+	}
+	sort.Strings(merged)
+	return merged
+}
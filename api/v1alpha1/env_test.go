@@ -0,0 +1,117 @@
+/*
+Copyright 2020 qingmu.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"reflect"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+func env(nameValues ...string) []v1.EnvVar {
+	env := make([]v1.EnvVar, 0, len(nameValues)/2)
+	for i := 0; i < len(nameValues); i += 2 {
+		env = append(env, v1.EnvVar{Name: nameValues[i], Value: nameValues[i+1]})
+	}
+	return env
+}
+
+var secretEnv = v1.EnvVar{
+	Name: "DB_PASSWORD",
+	ValueFrom: &v1.EnvVarSource{
+		SecretKeyRef: &v1.SecretKeySelector{
+			LocalObjectReference: v1.LocalObjectReference{Name: "db"},
+			Key:                  "password",
+		},
+	},
+}
+
+func TestMergeEnv(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      []v1.EnvVar
+		defaults []v1.EnvVar
+		want     []v1.EnvVar
+	}{
+		{"empty", nil, nil, env()},
+		{"defaults only, sorted", nil, env("B", "b", "A", "a"), env("A", "a", "B", "b")},
+		{"application order kept", env("Z", "z", "A", "a"), nil, env("Z", "z", "A", "a")},
+		{"application wins", env("A", "app"), env("A", "default", "B", "b"), env("A", "app", "B", "b")},
+		{"defaults appended sorted", env("Z", "z"), env("C", "c", "A", "a"), env("Z", "z", "A", "a", "C", "c")},
+		{"duplicated application env, last wins in place", env("A", "1", "B", "b", "A", "2"), nil, env("A", "2", "B", "b")},
+		{"duplicated defaults, last wins", nil, env("A", "1", "A", "2"), env("A", "2")},
+		{"value from", env("A", "a"), []v1.EnvVar{secretEnv}, []v1.EnvVar{{Name: "A", Value: "a"}, secretEnv}},
+		{"value from overridden", env("DB_PASSWORD", "plain"), []v1.EnvVar{secretEnv}, env("DB_PASSWORD", "plain")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := mergeEnv(tt.env, tt.defaults)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("mergeEnv() = %v, want %v", got, tt.want)
+			}
+			// the merge is idempotent, Check may run on an already defaulted spec
+			if again := mergeEnv(got, tt.defaults); !reflect.DeepEqual(again, got) {
+				t.Errorf("mergeEnv() again = %v, want %v", again, got)
+			}
+		})
+	}
+}
+
+func TestOverrideEnv(t *testing.T) {
+	tests := []struct {
+		name     string
+		base     []v1.EnvVar
+		override []v1.EnvVar
+		want     []v1.EnvVar
+	}{
+		{"empty", nil, nil, nil},
+		{"base only, sorted", env("B", "b", "A", "a"), nil, env("A", "a", "B", "b")},
+		{"override wins", env("A", "operator", "B", "b"), env("A", "namespace"), env("A", "namespace", "B", "b")},
+		{"override added", env("B", "b"), env("A", "a"), env("A", "a", "B", "b")},
+		{"value from replaces value", env("DB_PASSWORD", "plain"), []v1.EnvVar{secretEnv}, []v1.EnvVar{secretEnv}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := overrideEnv(tt.base, tt.override); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("overrideEnv() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergePullSecrets(t *testing.T) {
+	tests := []struct {
+		name     string
+		secrets  []string
+		defaults []string
+		want     []string
+	}{
+		{"empty", nil, nil, nil},
+		{"defaults only, sorted", nil, []string{"b", "a"}, []string{"a", "b"}},
+		{"merged and sorted", []string{"c", "a"}, []string{"b"}, []string{"a", "b", "c"}},
+		{"de-duplicated", []string{"a", "a"}, []string{"a", "b"}, []string{"a", "b"}},
+		{"empty names dropped", []string{""}, []string{"a"}, []string{"a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mergePullSecrets(tt.secrets, tt.defaults); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("mergePullSecrets() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -18,9 +18,12 @@ package v1alpha1
 
 import (
 	"fmt"
+	autoscalingv2beta2 "k8s.io/api/autoscaling/v2beta2"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"spring-boot-operator/global"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"strconv"
+	"strings"
 )
 
 // EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
@@ -33,6 +36,10 @@ type SpringBootApplicationSpec struct {
 
 	// The spring boot body
 	SpringBoot SpringBoot `json:"springBoot"`
+	// The SpringBootProfile the spring boot body is merged onto. Lists of named items,
+	// e.g. env, are merged by name, any other value set in the body overrides the profile.
+	// An unset value, false or 0 keeps the profile value, except for the optional fields, e.g. probes.healthGroups
+	ProfileRef string `json:"profileRef,omitempty"`
 }
 
 type SpringBoot struct {
@@ -56,20 +63,305 @@ type SpringBoot struct {
 	// The spring boot application path
 	// Liveness and Readiness  is '/actuator/health' by  default
 	// HostLog is '/var/applog' by default
-	// Shutdown is '/spring/shutdown' by default
+	// Shutdown is '/spring/shutdown' by default, requested by the HTTPGet shutdown mode
 	Path PathSpec `json:"path,omitempty"`
 	// The pull image secrets.
-	ImagePullSecrets []string `json:"imagePullSecrets,omitempty"`
+	ImagePullSecrets []string `json:"imagePullSecrets,omitempty" patchStrategy:"merge"`
 	// The spring boot application env.
-	Env []v1.EnvVar `json:"env,omitempty"`
+	Env []v1.EnvVar `json:"env,omitempty" patchStrategy:"merge" patchMergeKey:"name"`
 
+	// The node affinity of the pods
 	NodeAffinity NodeAffinitySpec `json:"nodeAffinity,omitempty"`
+	// The nodes the pods must be scheduled on, by node label
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+	// The taints tolerated by the pods
+	Tolerations []v1.Toleration `json:"tolerations,omitempty"`
+	// How the pods are spread across e.g. zones.
+	// The label selector matches the pods of the application when empty
+	TopologySpreadConstraints []v1.TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
+	// Soft, Hard or Off. Soft prefers, Hard requires the pods to run on different nodes. Soft by default
+	PodAntiAffinity string `json:"podAntiAffinity,omitempty"`
+	// The init containers run before the application, e.g. waiting for the database or running migrations
+	InitContainers []v1.Container `json:"initContainers,omitempty" patchStrategy:"merge" patchMergeKey:"name"`
+	// The containers running next to the application, e.g. a log shipper.
+	// They can mount the volumes generated for the application by name
+	Sidecars []v1.Container `json:"sidecars,omitempty" patchStrategy:"merge" patchMergeKey:"name"`
+	// Share a single process namespace between the containers of the pod. true by default
+	ShareProcessNamespace *bool `json:"shareProcessNamespace,omitempty"`
+	// HostPath, EmptyDir or None. The log volume mounted at Path.HostLog,
+	// HostPath mounts the same path of the node. HostPath by default
+	LogVolume string `json:"logVolume,omitempty"`
+	// Additional volumes of the pod, e.g. persistent volume claims or csi volumes
+	Volumes []v1.Volume `json:"volumes,omitempty" patchStrategy:"merge" patchMergeKey:"name"`
+	// Additional volume mounts of the application container, of Volumes or of the generated volumes.
+	// The paths of the generated mounts, e.g. Path.HostLog or /config, are taken
+	VolumeMounts []v1.VolumeMount `json:"volumeMounts,omitempty" patchStrategy:"merge" patchMergeKey:"mountPath"`
+	// The spring boot application horizontal pod autoscaler.
+	// When it is enabled the operator no longer sets the deployment replicas
+	Autoscaling AutoscalingSpec `json:"autoscaling,omitempty"`
+	// The spring boot application pod disruption budget.
+	// Only created when more than one replica is running, maxUnavailable 1 by default
+	PodDisruptionBudget PodDisruptionBudgetSpec `json:"podDisruptionBudget,omitempty"`
+	// The spring boot application ingress, pointing at the service port
+	Ingress IngressSpec `json:"ingress,omitempty"`
+	// The spring boot application service
+	Service ServiceSpec `json:"service,omitempty"`
+	// The spring boot actuator port (management.server.port), Port by default.
+	// The liveness, readiness and shutdown paths are requested on this port
+	ManagementPort int32 `json:"managementPort,omitempty"`
+	// The spring boot application configuration files, mounted at '/config'.
+	// The pods are restarted when the content changes
+	Config ConfigSpec `json:"config,omitempty"`
+	// The secrets mounted into the application or injected as env.
+	// The pods are restarted when one of them, or a secret referenced by Env, changes
+	Secrets []SecretSpec `json:"secrets,omitempty" patchStrategy:"merge" patchMergeKey:"name"`
+	// The spring boot version the application is built with, e.g. '2.3.4.RELEASE'.
+	// Only used to pick the probe paths, see Probes.HealthGroups
+	SpringBootVersion string `json:"springBootVersion,omitempty"`
+	// The timing of the liveness, readiness and startup probes
+	Probes ProbesSpec `json:"probes,omitempty"`
+	// The jvm options, computed into JAVA_TOOL_OPTIONS from the memory limit.
+	// A JAVA_TOOL_OPTIONS set in Env is appended and wins on conflicts
+	Jvm *JvmSpec `json:"jvm,omitempty"`
+	// The pod shutdown, the preStop hook and the spring boot graceful shutdown
+	Shutdown ShutdownSpec `json:"shutdown,omitempty"`
+	// The service account and the security context of the pods.
+	// Unset values use the operator defaults
+	Security SecuritySpec `json:"security,omitempty"`
+}
+
+type SecuritySpec struct {
+	// The service account of the pods. The application name when CreateServiceAccount is set,
+	// the namespace default service account otherwise
+	ServiceAccountName string `json:"serviceAccountName,omitempty"`
+	// Create the service account, owned by the application. false by default
+	CreateServiceAccount bool `json:"createServiceAccount,omitempty"`
+	// Mount the service account token into the pods
+	AutomountServiceAccountToken *bool `json:"automountServiceAccountToken,omitempty"`
+	// Refuse to start containers running as root
+	RunAsNonRoot *bool `json:"runAsNonRoot,omitempty"`
+	// The uid the containers run as. The image user by default
+	RunAsUser *int64 `json:"runAsUser,omitempty"`
+	// The group owning the mounted volumes
+	FSGroup *int64 `json:"fsGroup,omitempty"`
+	// Mount the root filesystem read only, an emptyDir is mounted at '/tmp' for the jvm
+	ReadOnlyRootFilesystem *bool `json:"readOnlyRootFilesystem,omitempty"`
+	// The capabilities dropped from the container, e.g. ALL.
+	// An empty list drops none, overriding the default
+	DropCapabilities *[]v1.Capability `json:"dropCapabilities,omitempty"`
+	// The seccomp profile of the pod: runtime/default, unconfined or localhost/<profile>
+	SeccompProfile string `json:"seccompProfile,omitempty"`
+}
+
+// The preStop hook modes supported by ShutdownSpec.Mode
+const (
+	// An http get on Path.Shutdown
+	ShutdownModeHTTPGet = "HTTPGet"
+	// Sleep so the pod is removed from the service endpoints before spring boot stops
+	ShutdownModeSleep = "Sleep"
+	// No preStop hook
+	ShutdownModeNone = "None"
+)
+
+type ShutdownSpec struct {
+	// HTTPGet, Sleep or None. HTTPGet by default.
+	// Sleep runs 'sleep' in the container, the image must provide it
+	Mode string `json:"mode,omitempty"`
+	// The seconds the Sleep hook waits. 10 by default
+	SleepSeconds int32 `json:"sleepSeconds,omitempty"`
+	// Enables the spring boot 2.3+ graceful shutdown (server.shutdown=graceful) and waits at most
+	// this many seconds for the active requests (spring.lifecycle.timeout-per-shutdown-phase).
+	// Disabled by default
+	GracefulTimeoutSeconds int32 `json:"gracefulTimeoutSeconds,omitempty"`
+	// The seconds the pod is given to stop, including the preStop hook.
+	// 30 by default, raised to fit the sleep and the graceful timeout
+	TerminationGracePeriodSeconds *int64 `json:"terminationGracePeriodSeconds,omitempty"`
+}
+
+// The garbage collectors supported by JvmSpec.GC
+const (
+	GCG1         = "G1"
+	GCParallel   = "Parallel"
+	GCSerial     = "Serial"
+	GCShenandoah = "Shenandoah"
+	GCZ          = "Z"
+)
+
+type JvmSpec struct {
+	// The maximum heap (-Xmx) in percent of the memory limit. 75 by default.
+	// -XX:MaxRAMPercentage is used when the memory is unlimited
+	HeapPercentage int32 `json:"heapPercentage,omitempty"`
+	// The maximum metaspace (-XX:MaxMetaspaceSize), e.g. '256Mi'. Unlimited by default
+	MaxMetaspaceSize string `json:"maxMetaspaceSize,omitempty"`
+	// G1, Parallel, Serial, Shenandoah or Z. The jvm default by default.
+	// Shenandoah and Z are unlocked with -XX:+UnlockExperimentalVMOptions, experimental before jdk 15
+	GC string `json:"gc,omitempty"`
+	// Exit on java.lang.OutOfMemoryError so the pod is restarted. true by default
+	ExitOnOutOfMemoryError *bool `json:"exitOnOutOfMemoryError,omitempty"`
+	// Dump the heap into this directory on java.lang.OutOfMemoryError, e.g. the HostLog path. No dump by default
+	HeapDumpPath string `json:"heapDumpPath,omitempty"`
+	// Additional jvm options appended to the computed ones
+	Options []string `json:"options,omitempty"`
+}
+
+// The heap sizes of the jvm
+const (
+	DefaultHeapPercentage = 75
+	// the jvm refuses to start with a smaller heap
+	MinHeapSize = 2 * 1024 * 1024
+	// the metaspace holds at least the classes of the jdk
+	MinMetaspaceSize = 1024 * 1024
+)
+
+// The spring boot 2.3+ liveness and readiness health groups
+const (
+	HealthGroupLivenessPath  = "/actuator/health/liveness"
+	HealthGroupReadinessPath = "/actuator/health/readiness"
+)
+
+type ProbesSpec struct {
+	// Probe the spring boot 2.3+ health groups '/actuator/health/liveness' and '/actuator/health/readiness'
+	// instead of the default liveness and readiness paths. Path.Liveness and Path.Readiness, when set, are kept.
+	// true by default when SpringBootVersion is 2.3 or later
+	HealthGroups *bool `json:"healthGroups,omitempty"`
+	// The liveness probe timing
+	Liveness ProbeSpec `json:"liveness,omitempty"`
+	// The readiness probe timing
+	Readiness ProbeSpec `json:"readiness,omitempty"`
+	// The startup probe, requested on the liveness path. The liveness and readiness probes
+	// only start once it succeeds. No startup probe by default
+	Startup *ProbeSpec `json:"startup,omitempty"`
+}
+
+type ProbeSpec struct {
+	// Seconds after the container started before the probe is initiated. 0 by default
+	InitialDelaySeconds int32 `json:"initialDelaySeconds,omitempty"`
+	// How often the probe is performed. 10 by default
+	PeriodSeconds int32 `json:"periodSeconds,omitempty"`
+	// Seconds after which the probe times out. 1 by default
+	TimeoutSeconds int32 `json:"timeoutSeconds,omitempty"`
+	// Consecutive failures before the probe is considered failed.
+	// 3 by default, 30 by default for the startup probe
+	FailureThreshold int32 `json:"failureThreshold,omitempty"`
+}
+
+type SecretSpec struct {
+	// The name of the secret in the namespace of the application
+	Name string `json:"name"`
+	// The path the secret is mounted at, e.g. '/secrets/db'. Injected as env when empty
+	MountPath string `json:"mountPath,omitempty"`
+	// The prefix prepended to the keys of the secret when it is injected as env
+	EnvPrefix string `json:"envPrefix,omitempty"`
+}
+
+// ConfigMountPath is where the configuration files are mounted
+const ConfigMountPath = "/config"
+
+type ConfigSpec struct {
+	// Inline configuration files by file name, e.g. application.yml or application-prod.yml
+	Files map[string]string `json:"files,omitempty"`
+	// An existing ConfigMap holding the configuration files, used instead of Files
+	ConfigMapName string `json:"configMapName,omitempty"`
+}
+
+// The service types, Headless is a ClusterIP service without cluster ip
+const (
+	ServiceTypeClusterIP    = "ClusterIP"
+	ServiceTypeNodePort     = "NodePort"
+	ServiceTypeLoadBalancer = "LoadBalancer"
+	ServiceTypeHeadless     = "Headless"
+)
+
+type ServiceSpec struct {
+	// ClusterIP, NodePort, LoadBalancer or Headless. ClusterIP by default
+	Type string `json:"type,omitempty"`
+	// The node port of the application port, allocated by kubernetes by default
+	NodePort int32 `json:"nodePort,omitempty"`
+	// Additional ports of the service, the application port is always exposed
+	Ports []ServicePortSpec `json:"ports,omitempty"`
+	// The service annotations, e.g. the load balancer settings
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// None or ClientIP. None by default
+	SessionAffinity v1.ServiceAffinity `json:"sessionAffinity,omitempty"`
+	// Cluster or Local, only used by NodePort and LoadBalancer services. Cluster by default
+	ExternalTrafficPolicy v1.ServiceExternalTrafficPolicyType `json:"externalTrafficPolicy,omitempty"`
 }
 
+type ServicePortSpec struct {
+	// The name of the port, must be unique within the service
+	Name string `json:"name"`
+	// The port exposed by the service
+	Port int32 `json:"port"`
+	// The container port, Port by default
+	TargetPort intstr.IntOrString `json:"targetPort,omitempty"`
+	// The node port, only used by NodePort and LoadBalancer services
+	NodePort int32 `json:"nodePort,omitempty"`
+	// TCP, UDP or SCTP. TCP by default
+	Protocol v1.Protocol `json:"protocol,omitempty"`
+}
+
+type IngressSpec struct {
+	// Create an Ingress for the application. false by default
+	Enabled bool `json:"enabled,omitempty"`
+	// The hosts routed to the application, all hosts by default
+	Hosts []string `json:"hosts,omitempty"`
+	// The paths routed to the application, '/' by default
+	Paths []string `json:"paths,omitempty"`
+	// The secret holding the tls certificate of the hosts, tls is disabled when empty
+	TLSSecretName string `json:"tlsSecretName,omitempty"`
+	// The ingress class, set as the 'kubernetes.io/ingress.class' annotation
+	IngressClass string `json:"ingressClass,omitempty"`
+	// The ingress annotations, e.g. the ingress controller settings
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+type PodDisruptionBudgetSpec struct {
+	// Do not create a PodDisruptionBudget. false by default
+	Disabled bool `json:"disabled,omitempty"`
+	// The number or percentage of pods that must still be available after an eviction
+	MinAvailable *intstr.IntOrString `json:"minAvailable,omitempty"`
+	// The number or percentage of pods that can be unavailable after an eviction
+	MaxUnavailable *intstr.IntOrString `json:"maxUnavailable,omitempty"`
+}
+
+type AutoscalingSpec struct {
+	// Create a HorizontalPodAutoscaler for the application. false by default
+	Enabled bool `json:"enabled,omitempty"`
+	// The lower limit of replicas. Replicas by default
+	MinReplicas *int32 `json:"minReplicas,omitempty"`
+	// The upper limit of replicas, required when autoscaling is enabled
+	MaxReplicas int32 `json:"maxReplicas,omitempty"`
+	// The target average cpu utilization of the requested cpu, in percent
+	TargetCPUUtilizationPercentage *int32 `json:"targetCPUUtilizationPercentage,omitempty"`
+	// The target average memory utilization of the requested memory, in percent
+	TargetMemoryUtilizationPercentage *int32 `json:"targetMemoryUtilizationPercentage,omitempty"`
+	// Additional metrics, e.g. pods or external custom metrics
+	Metrics []autoscalingv2beta2.MetricSpec `json:"metrics,omitempty"`
+}
+
+// The log volumes supported by SpringBoot.LogVolume
+const (
+	LogVolumeHostPath = "HostPath"
+	LogVolumeEmptyDir = "EmptyDir"
+	LogVolumeNone     = "None"
+)
+
+// The pod anti-affinity modes supported by SpringBoot.PodAntiAffinity
+const (
+	PodAntiAffinitySoft = "Soft"
+	PodAntiAffinityHard = "Hard"
+	PodAntiAffinityOff  = "Off"
+)
+
 type NodeAffinitySpec struct {
-	Key      string   `json:"key"`
-	Operator string   `json:"operator"`
-	Values   []string `json:"values"`
+	// A single requirement the nodes must match, added to every Required term
+	Key      string   `json:"key,omitempty"`
+	Operator string   `json:"operator,omitempty"`
+	Values   []string `json:"values,omitempty"`
+	// The node selector terms, the pods are scheduled on nodes matching one of them
+	Required []v1.NodeSelectorTerm `json:"required,omitempty"`
+	// The weighted node selector terms, nodes matching them are preferred
+	Preferred []v1.PreferredSchedulingTerm `json:"preferred,omitempty"`
 }
 
 type ResourceSpec struct {
@@ -79,6 +371,34 @@ type ResourceSpec struct {
 	Memory MemorySpec `json:"memory,omitempty"`
 }
 
+// ResourceRequirements parses the quantities of the resource spec, empty values are left unset
+func (r *ResourceSpec) ResourceRequirements() (v1.ResourceRequirements, error) {
+	requirements := v1.ResourceRequirements{
+		Requests: v1.ResourceList{},
+		Limits:   v1.ResourceList{},
+	}
+	quantities := []struct {
+		list  v1.ResourceList
+		name  v1.ResourceName
+		value string
+	}{
+		{requirements.Requests, v1.ResourceCPU, r.Cpu.Request},
+		{requirements.Requests, v1.ResourceMemory, r.Memory.Request},
+		{requirements.Limits, v1.ResourceCPU, r.Cpu.Limit},
+		{requirements.Limits, v1.ResourceMemory, r.Memory.Limit},
+	}
+	for _, q := range quantities {
+		quantity, err := parseQuantity(q.value)
+		if err != nil {
+			return requirements, fmt.Errorf("invalid %s quantity %q: %v", q.name, q.value, err)
+		}
+		if quantity != nil {
+			q.list[q.name] = *quantity
+		}
+	}
+	return requirements, nil
+}
+
 type CpuSpec struct {
 	// 100m Request Cpu by default.
 	Request string `json:"request,omitempty"`
@@ -104,13 +424,65 @@ type PathSpec struct {
 	Shutdown string `json:"shutdown,omitempty"`
 }
 
+// The condition types reported in SpringBootApplicationStatus.Conditions
+const (
+	// The deployment has the minimum number of ready replicas
+	ConditionAvailable = "Available"
+	// A rollout of the deployment is in progress
+	ConditionProgressing = "Progressing"
+	// The rollout failed or a replica could not be created
+	ConditionDegraded = "Degraded"
+	// The last reconcile of the application failed
+	ConditionReconcileError = "ReconcileError"
+	// The spec, with the operator defaults applied, failed validation
+	ConditionInvalidSpec = "InvalidSpec"
+)
+
+// Condition mirrors the upstream metav1.Condition type
+type Condition struct {
+	// Type of the condition, one of Available, Progressing, Degraded, ReconcileError, InvalidSpec
+	Type string `json:"type"`
+	// Status of the condition, one of True, False, Unknown
+	Status v1.ConditionStatus `json:"status"`
+	// The .metadata.generation that the condition was set based upon
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Last time the condition transitioned from one status to another
+	LastTransitionTime metav1.Time `json:"lastTransitionTime"`
+	// A programmatic identifier indicating the reason for the condition's last transition
+	Reason string `json:"reason"`
+	// A human readable message indicating details about the transition
+	Message string `json:"message,omitempty"`
+}
+
 // SpringBootApplicationStatus defines the observed state of SpringBootApplication
 type SpringBootApplicationStatus struct {
-	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
-	// Important: Run "make" to regenerate code after modifying this file
+	// The most recent generation observed by the operator
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// The resolved image of the spring boot application
+	Image string `json:"image,omitempty"`
+	// The cluster ip of the generated service
+	ClusterIp string `json:"clusterIp,omitempty"`
+	// The desired replicas of the generated deployment
+	Replicas int32 `json:"replicas,omitempty"`
+	// The ready replicas of the generated deployment
+	ReadyReplicas int32 `json:"readyReplicas,omitempty"`
+	// The updated replicas of the generated deployment
+	UpdatedReplicas int32 `json:"updatedReplicas,omitempty"`
+	// The available replicas of the generated deployment
+	AvailableReplicas int32 `json:"availableReplicas,omitempty"`
+	// The latest available observations of the application's state
+	// +patchMergeKey=type
+	// +patchStrategy=merge
+	Conditions []Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
 }
 
 // +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:shortName=sba
+// +kubebuilder:printcolumn:name="Image",type="string",JSONPath=".status.image"
+// +kubebuilder:printcolumn:name="Desired",type="integer",JSONPath=".status.replicas"
+// +kubebuilder:printcolumn:name="Ready",type="integer",JSONPath=".status.readyReplicas"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 
 // SpringBootApplication is the Schema for the springbootapplications API
 type SpringBootApplication struct {
@@ -134,8 +506,9 @@ func init() {
 	SchemeBuilder.Register(&SpringBootApplication{}, &SpringBootApplicationList{})
 }
 
-func (s *SpringBoot) Check(Name string) (*SpringBoot, error) {
-	config := global.GetGlobalConfig()
+// Check applies the defaults to the unset values, the namespace defaults override the operator defaults in order
+func (s *SpringBoot) Check(Name string, namespaceDefaults ...*SpringBootDefaultsSpec) (*SpringBoot, error) {
+	config := effectiveDefaults(namespaceDefaults)
 	if s.Image == "" {
 		image := fmt.Sprintf("%s/%s:%s", config.ImageRepository, Name, s.Version)
 		s.Image = image
@@ -147,10 +520,11 @@ func (s *SpringBoot) Check(Name string) (*SpringBoot, error) {
 	if s.Path.HostLog == "" {
 		s.Path.HostLog = config.HostLogPath
 	}
-	if s.Path.Liveness == "" {
+	// the health groups replace the default paths, not the ones set by the user
+	if s.Path.Liveness == "" && !s.UseHealthGroups() {
 		s.Path.Liveness = config.LivenessPath
 	}
-	if s.Path.Readiness == "" {
+	if s.Path.Readiness == "" && !s.UseHealthGroups() {
 		s.Path.Readiness = config.ReadinessPath
 	}
 	if s.Replicas == 0 {
@@ -172,33 +546,184 @@ func (s *SpringBoot) Check(Name string) (*SpringBoot, error) {
 	if s.ClusterIp == "" {
 		//s.ClusterIp = "None"
 	}
-	if s.Env == nil {
-		s.Env = []v1.EnvVar{}
-	}
-	envStringMap := make(map[string]string)
-	for _, v := range s.Env {
-		envStringMap[v.Name] = v.Value
-	}
-	if len(config.Env) > 0 {
-		for k, v := range config.Env {
-			if _, ok := envStringMap[k]; !ok {
-				s.Env = append(s.Env, v1.EnvVar{
-					Name:  k,
-					Value: v,
-				})
-			}
-		}
-	}
+	s.Env = mergeEnv(s.Env, config.Env)
 
 	if s.Port == 0 {
 		s.Port = config.Port
 	}
 
-	if len(config.ImagePullSecrets) > 0 {
-		for _, secret := range config.ImagePullSecrets {
-			s.ImagePullSecrets = append(s.ImagePullSecrets, secret)
+	security := &s.Security
+	if security.AutomountServiceAccountToken == nil {
+		security.AutomountServiceAccountToken = config.AutomountServiceAccountToken
+	}
+	if security.RunAsNonRoot == nil {
+		security.RunAsNonRoot = config.RunAsNonRoot
+	}
+	if security.RunAsUser == nil {
+		security.RunAsUser = config.RunAsUser
+	}
+	if security.FSGroup == nil {
+		security.FSGroup = config.FSGroup
+	}
+	if security.ReadOnlyRootFilesystem == nil {
+		security.ReadOnlyRootFilesystem = config.ReadOnlyRootFilesystem
+	}
+	if security.DropCapabilities == nil && config.DropCapabilities != nil {
+		capabilities := make([]v1.Capability, 0, len(*config.DropCapabilities))
+		for _, capability := range *config.DropCapabilities {
+			capabilities = append(capabilities, v1.Capability(capability))
+		}
+		security.DropCapabilities = &capabilities
+	}
+	if security.SeccompProfile == "" {
+		security.SeccompProfile = config.SeccompProfile
+	}
+
+	if s.Tolerations == nil && len(config.Tolerations) > 0 {
+		s.Tolerations = append([]v1.Toleration{}, config.Tolerations...)
+	}
+	if s.NodeSelector == nil && len(config.NodeSelector) > 0 {
+		s.NodeSelector = make(map[string]string, len(config.NodeSelector))
+		for k, v := range config.NodeSelector {
+			s.NodeSelector[k] = v
 		}
 	}
+	if s.TopologySpreadConstraints == nil && len(config.TopologySpreadConstraints) > 0 {
+		s.TopologySpreadConstraints = append([]v1.TopologySpreadConstraint{}, config.TopologySpreadConstraints...)
+	}
+	if s.PodAntiAffinity == "" {
+		s.PodAntiAffinity = config.PodAntiAffinity
+	}
+	if s.NodeAffinity.Key == "" && config.NodeAffinityKey != "" {
+		s.NodeAffinity.Key = config.NodeAffinityKey
+		s.NodeAffinity.Operator = config.NodeAffinityOperator
+		s.NodeAffinity.Values = append([]string{}, config.NodeAffinityValues...)
+	}
+
+	s.ImagePullSecrets = mergePullSecrets(s.ImagePullSecrets, config.ImagePullSecrets)
 
 	return s, nil
 }
+
+// SetCondition adds or replaces the condition of the same type,
+// LastTransitionTime is only changed when the status changes
+func (s *SpringBootApplicationStatus) SetCondition(condition Condition) {
+	if condition.LastTransitionTime.IsZero() {
+		condition.LastTransitionTime = metav1.Now()
+	}
+	for i := range s.Conditions {
+		existing := &s.Conditions[i]
+		if existing.Type != condition.Type {
+			continue
+		}
+		if existing.Status == condition.Status {
+			condition.LastTransitionTime = existing.LastTransitionTime
+		}
+		*existing = condition
+		return
+	}
+	s.Conditions = append(s.Conditions, condition)
+}
+
+// GetCondition returns the condition of the given type, nil if it is not set
+func (s *SpringBootApplicationStatus) GetCondition(conditionType string) *Condition {
+	for i := range s.Conditions {
+		if s.Conditions[i].Type == conditionType {
+			return &s.Conditions[i]
+		}
+	}
+	return nil
+}
+
+// MinReplicas returns the lowest number of replicas the application is scaled to. Without
+// autoscaling.minReplicas the autoscaler scales down to the replicas, kept between 1 and autoscaling.maxReplicas
+func (s *SpringBoot) MinReplicas() int32 {
+	if !s.Autoscaling.Enabled {
+		return s.Replicas
+	}
+	if s.Autoscaling.MinReplicas != nil {
+		return *s.Autoscaling.MinReplicas
+	}
+	minReplicas := s.Replicas
+	if s.Autoscaling.MaxReplicas > 0 && minReplicas > s.Autoscaling.MaxReplicas {
+		minReplicas = s.Autoscaling.MaxReplicas
+	}
+	if minReplicas < 1 {
+		minReplicas = 1
+	}
+	return minReplicas
+}
+
+// ProbePort returns the port serving the actuator endpoints
+func (s *SpringBoot) ProbePort() int32 {
+	if s.ManagementPort != 0 {
+		return s.ManagementPort
+	}
+	return s.Port
+}
+
+// UseHealthGroups reports whether the probes target the spring boot 2.3+ health groups
+func (s *SpringBoot) UseHealthGroups() bool {
+	if s.Probes.HealthGroups != nil {
+		return *s.Probes.HealthGroups
+	}
+	major, minor, err := parseSpringBootVersion(s.SpringBootVersion)
+	if err != nil {
+		return false
+	}
+	return major > 2 || major == 2 && minor >= 3
+}
+
+// parseSpringBootVersion returns the major and minor version of e.g. '2.3.4.RELEASE' or '2.4.0-M1'
+func parseSpringBootVersion(version string) (int, int, error) {
+	parts := strings.SplitN(strings.TrimPrefix(version, "v"), ".", 3)
+	if len(parts) < 2 {
+		return 0, 0, fmt.Errorf("%q is not a major.minor version", version)
+	}
+	major, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, fmt.Errorf("%q is not a major.minor version", version)
+	}
+	// the minor version may carry a qualifier, e.g. '4-M1'
+	digits := strings.IndexFunc(parts[1], func(r rune) bool { return r < '0' || r > '9' })
+	if digits < 0 {
+		digits = len(parts[1])
+	}
+	minor, err := strconv.Atoi(parts[1][:digits])
+	if err != nil {
+		return 0, 0, fmt.Errorf("%q is not a major.minor version", version)
+	}
+	return major, minor, nil
+}
+
+// TerminationGracePeriodSeconds returns the seconds the pod is given to stop, leaving 5 seconds
+// to spare after the preStop sleep and the graceful shutdown
+func (s *SpringBoot) TerminationGracePeriodSeconds() int64 {
+	if s.Shutdown.TerminationGracePeriodSeconds != nil {
+		return *s.Shutdown.TerminationGracePeriodSeconds
+	}
+	seconds := int64(s.Shutdown.GracefulTimeoutSeconds) + 5
+	if s.Shutdown.Mode == ShutdownModeSleep {
+		seconds += int64(s.ShutdownSleepSeconds())
+	}
+	if seconds < 30 {
+		seconds = 30
+	}
+	return seconds
+}
+
+// ShutdownSleepSeconds returns the seconds the Sleep hook waits
+func (s *SpringBoot) ShutdownSleepSeconds() int32 {
+	if s.Shutdown.SleepSeconds != 0 {
+		return s.Shutdown.SleepSeconds
+	}
+	return 10
+}
+
+// ServiceAccountName returns the service account of the pods, empty for the namespace default
+func (s *SpringBoot) ServiceAccountName(name string) string {
+	if s.Security.ServiceAccountName == "" && s.Security.CreateServiceAccount {
+		return name
+	}
+	return s.Security.ServiceAccountName
+}
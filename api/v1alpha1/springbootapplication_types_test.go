@@ -0,0 +1,102 @@
+/*
+Copyright 2020 qingmu.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	"spring-boot-operator/global"
+)
+
+func int32Ptr(i int32) *int32 {
+	return &i
+}
+
+func TestMinReplicas(t *testing.T) {
+	tests := []struct {
+		name        string
+		replicas    int32
+		autoscaling AutoscalingSpec
+		want        int32
+		valid       bool
+	}{
+		{"without autoscaling", 3, AutoscalingSpec{MinReplicas: int32Ptr(5)}, 3, true},
+		{"min replicas", 3, AutoscalingSpec{Enabled: true, MinReplicas: int32Ptr(2), MaxReplicas: 5}, 2, true},
+		{"replicas", 3, AutoscalingSpec{Enabled: true, MaxReplicas: 5}, 3, true},
+		{"replicas capped at max replicas", 8, AutoscalingSpec{Enabled: true, MaxReplicas: 5}, 5, true},
+		{"replicas floored at 1", 0, AutoscalingSpec{Enabled: true, MaxReplicas: 5}, 1, true},
+		{"min replicas above max replicas", 1, AutoscalingSpec{Enabled: true, MinReplicas: int32Ptr(6), MaxReplicas: 5}, 6, false},
+		{"min replicas below 1", 1, AutoscalingSpec{Enabled: true, MinReplicas: int32Ptr(0), MaxReplicas: 5}, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			springBoot := &SpringBoot{Replicas: tt.replicas, Autoscaling: tt.autoscaling}
+			if got := springBoot.MinReplicas(); got != tt.want {
+				t.Errorf("MinReplicas() = %d, want %d", got, tt.want)
+			}
+			errs := springBoot.Autoscaling.validate(field.NewPath("autoscaling"), springBoot)
+			if valid := len(errs) == 0; valid != tt.valid {
+				t.Errorf("validate() = %v, want valid %v", errs, tt.valid)
+			}
+		})
+	}
+}
+
+func TestDropCapabilities(t *testing.T) {
+	global.GetGlobalConfig().DropCapabilities = []string{"ALL"}
+	defer func() { global.GetGlobalConfig().DropCapabilities = nil }()
+	namespaceDrop := []string{"NET_RAW"}
+	tests := []struct {
+		name              string
+		spec              string
+		namespaceDefaults []*SpringBootDefaultsSpec
+		want              []v1.Capability
+	}{
+		{"operator default", `{}`, nil, []v1.Capability{"ALL"}},
+		{"explicit", `{"security":{"dropCapabilities":["NET_RAW"]}}`, nil, []v1.Capability{"NET_RAW"}},
+		{"explicit empty list opts out", `{"security":{"dropCapabilities":[]}}`, nil, []v1.Capability{}},
+		{"namespace default", `{}`, []*SpringBootDefaultsSpec{{DropCapabilities: &namespaceDrop}}, []v1.Capability{"NET_RAW"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			springBoot := &SpringBoot{}
+			if err := json.Unmarshal([]byte(tt.spec), springBoot); err != nil {
+				t.Fatal(err)
+			}
+			// the explicit empty list survives the defaulted spec written back by the webhook
+			raw, err := json.Marshal(springBoot)
+			if err != nil {
+				t.Fatal(err)
+			}
+			springBoot = &SpringBoot{Version: "v1"}
+			if err := json.Unmarshal(raw, springBoot); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := springBoot.Check("demo", tt.namespaceDefaults...); err != nil {
+				t.Fatal(err)
+			}
+			if springBoot.Security.DropCapabilities == nil || !reflect.DeepEqual(*springBoot.Security.DropCapabilities, tt.want) {
+				t.Errorf("dropCapabilities = %v, want %v", springBoot.Security.DropCapabilities, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,801 @@
+/*
+Copyright 2020 qingmu.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+// webhookReader reads the profiles and applications the validation of an object depends on
+var webhookReader client.Reader
+
+// SetupWebhookWithManager registers the validating webhook. There is no defaulting webhook, the operator
+// and namespace defaults are applied on every reconcile so a change of either reaches the existing applications
+func (r *SpringBootApplication) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	webhookReader = mgr.GetClient()
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+// +kubebuilder:webhook:verbs=create;update,path=/validate-springboot-qingmu-io-v1alpha1-springbootapplication,mutating=false,failurePolicy=fail,groups=springboot.qingmu.io,resources=springbootapplications,versions=v1alpha1,name=vspringbootapplication.kb.io
+
+var _ webhook.Validator = &SpringBootApplication{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
+func (r *SpringBootApplication) ValidateCreate() error {
+	return r.validate()
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
+func (r *SpringBootApplication) ValidateUpdate(old runtime.Object) error {
+	return r.validate()
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (r *SpringBootApplication) ValidateDelete() error {
+	return nil
+}
+
+// validate checks the spring boot body as the operator resolves it, merged onto the profile. An application
+// referencing a profile that does not exist yet is accepted, the reconcile reports the missing profile
+func (r *SpringBootApplication) validate() error {
+	springBoot := &r.Spec.SpringBoot
+	if r.Spec.ProfileRef != "" {
+		if webhookReader == nil {
+			return nil
+		}
+		profile := &SpringBootProfile{}
+		if err := webhookReader.Get(context.Background(), types.NamespacedName{Name: r.Spec.ProfileRef}, profile); err != nil {
+			if apierrors.IsNotFound(err) {
+				return nil
+			}
+			return err
+		}
+		merged, err := profile.Merge(springBoot)
+		if err != nil {
+			return err
+		}
+		springBoot = merged
+	}
+	if errs := springBoot.validateApplication(field.NewPath("spec", "springBoot"), r.Name); len(errs) > 0 {
+		return apierrors.NewInvalid(GroupVersion.WithKind("SpringBootApplication").GroupKind(), r.Name, errs)
+	}
+	return nil
+}
+
+// validateApplication runs the checks of the resolved spring boot body of the application name
+func (s *SpringBoot) validateApplication(fldPath *field.Path, name string) field.ErrorList {
+	errs := s.Validate(fldPath)
+	errs = append(errs, s.ValidateContainerNames(fldPath, name)...)
+	errs = append(errs, s.ValidateServicePorts(fldPath, name)...)
+	return errs
+}
+
+// Validate checks the values set by the user, empty values are left to the operator defaults
+func (s *SpringBoot) Validate(fldPath *field.Path) field.ErrorList {
+	var errs field.ErrorList
+
+	if s.Image == "" && s.Version == "" {
+		errs = append(errs, field.Required(fldPath.Child("version"), "version is required when image is empty"))
+	}
+	if s.Port < 0 || s.Port > 65535 {
+		errs = append(errs, field.Invalid(fldPath.Child("port"), s.Port, "must be between 1 and 65535"))
+	}
+	if s.Replicas < 0 {
+		errs = append(errs, field.Invalid(fldPath.Child("replicas"), s.Replicas, "must be greater than or equal to 0"))
+	}
+
+	resourcePath := fldPath.Child("resource")
+	errs = append(errs, validateQuantities(resourcePath.Child("cpu"), s.Resource.Cpu.Request, s.Resource.Cpu.Limit)...)
+	errs = append(errs, validateQuantities(resourcePath.Child("memory"), s.Resource.Memory.Request, s.Resource.Memory.Limit)...)
+
+	pathPath := fldPath.Child("path")
+	errs = append(errs, validatePath(pathPath.Child("liveness"), s.Path.Liveness)...)
+	errs = append(errs, validatePath(pathPath.Child("readiness"), s.Path.Readiness)...)
+	errs = append(errs, validatePath(pathPath.Child("hostLog"), s.Path.HostLog)...)
+	errs = append(errs, validatePath(pathPath.Child("shutdown"), s.Path.Shutdown)...)
+
+	errs = append(errs, s.NodeAffinity.validate(fldPath.Child("nodeAffinity"))...)
+	errs = append(errs, s.validateScheduling(fldPath)...)
+	errs = append(errs, s.validateContainers(fldPath)...)
+	errs = append(errs, s.validateVolumes(fldPath)...)
+	errs = append(errs, s.Autoscaling.validate(fldPath.Child("autoscaling"), s)...)
+	errs = append(errs, s.PodDisruptionBudget.validate(fldPath.Child("podDisruptionBudget"))...)
+	errs = append(errs, s.Ingress.validate(fldPath.Child("ingress"))...)
+	errs = append(errs, s.Service.validate(fldPath.Child("service"), s)...)
+	errs = append(errs, s.Config.validate(fldPath.Child("config"))...)
+	for i := range s.Secrets {
+		errs = append(errs, s.Secrets[i].validate(fldPath.Child("secrets").Index(i))...)
+	}
+	if s.ManagementPort < 0 || s.ManagementPort > 65535 {
+		errs = append(errs, field.Invalid(fldPath.Child("managementPort"), s.ManagementPort, "must be between 1 and 65535"))
+	}
+	if s.SpringBootVersion != "" {
+		if _, _, err := parseSpringBootVersion(s.SpringBootVersion); err != nil {
+			errs = append(errs, field.Invalid(fldPath.Child("springBootVersion"), s.SpringBootVersion, err.Error()))
+		}
+	}
+	errs = append(errs, s.Probes.validate(fldPath.Child("probes"))...)
+	if s.Jvm != nil {
+		errs = append(errs, s.Jvm.validate(fldPath.Child("jvm"), s)...)
+	}
+	errs = append(errs, s.Shutdown.validate(fldPath.Child("shutdown"), s)...)
+	errs = append(errs, s.Security.validate(fldPath.Child("security"))...)
+	return errs
+}
+
+// validateQuantities checks that request and limit parse and that request does not exceed limit
+func validateQuantities(fldPath *field.Path, request, limit string) field.ErrorList {
+	var errs field.ErrorList
+	requestQuantity, requestErr := parseQuantity(request)
+	if requestErr != nil {
+		errs = append(errs, field.Invalid(fldPath.Child("request"), request, requestErr.Error()))
+	}
+	limitQuantity, limitErr := parseQuantity(limit)
+	if limitErr != nil {
+		errs = append(errs, field.Invalid(fldPath.Child("limit"), limit, limitErr.Error()))
+	}
+	if requestQuantity != nil && limitQuantity != nil && requestQuantity.Cmp(*limitQuantity) > 0 {
+		errs = append(errs, field.Invalid(fldPath.Child("request"), request, "must be less than or equal to limit "+limit))
+	}
+	return errs
+}
+
+// parseQuantity returns nil without error for an empty value
+func parseQuantity(value string) (*resource.Quantity, error) {
+	if value == "" {
+		return nil, nil
+	}
+	quantity, err := resource.ParseQuantity(value)
+	if err != nil {
+		return nil, err
+	}
+	return &quantity, nil
+}
+
+func validatePath(fldPath *field.Path, path string) field.ErrorList {
+	if path != "" && !strings.HasPrefix(path, "/") {
+		return field.ErrorList{field.Invalid(fldPath, path, "must start with '/'")}
+	}
+	return nil
+}
+
+func (n *NodeAffinitySpec) validate(fldPath *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	if n.Key == "" {
+		if n.Operator != "" || len(n.Values) > 0 {
+			errs = append(errs, field.Required(fldPath.Child("key"), "key is required when operator or values are set"))
+		}
+	} else {
+		errs = append(errs, validateNodeSelectorRequirement(fldPath, v1.NodeSelectorRequirement{
+			Key:      n.Key,
+			Operator: v1.NodeSelectorOperator(n.Operator),
+			Values:   n.Values,
+		})...)
+	}
+	for i, term := range n.Required {
+		errs = append(errs, validateNodeSelectorTerm(fldPath.Child("required").Index(i), term)...)
+	}
+	for i, term := range n.Preferred {
+		termPath := fldPath.Child("preferred").Index(i)
+		if term.Weight < 1 || term.Weight > 100 {
+			errs = append(errs, field.Invalid(termPath.Child("weight"), term.Weight, "must be between 1 and 100"))
+		}
+		errs = append(errs, validateNodeSelectorTerm(termPath.Child("preference"), term.Preference)...)
+	}
+	return errs
+}
+
+func validateNodeSelectorTerm(fldPath *field.Path, term v1.NodeSelectorTerm) field.ErrorList {
+	var errs field.ErrorList
+	if len(term.MatchExpressions) == 0 && len(term.MatchFields) == 0 {
+		errs = append(errs, field.Required(fldPath, "matchExpressions or matchFields is required"))
+	}
+	for i, requirement := range term.MatchExpressions {
+		errs = append(errs, validateNodeSelectorRequirement(fldPath.Child("matchExpressions").Index(i), requirement)...)
+	}
+	for i, requirement := range term.MatchFields {
+		errs = append(errs, validateNodeSelectorRequirement(fldPath.Child("matchFields").Index(i), requirement)...)
+	}
+	return errs
+}
+
+func validateNodeSelectorRequirement(fldPath *field.Path, requirement v1.NodeSelectorRequirement) field.ErrorList {
+	var errs field.ErrorList
+	if requirement.Key == "" {
+		errs = append(errs, field.Required(fldPath.Child("key"), "key is required"))
+	}
+	valuesPath := fldPath.Child("values")
+	operator, values := string(requirement.Operator), requirement.Values
+	switch requirement.Operator {
+	case v1.NodeSelectorOpIn, v1.NodeSelectorOpNotIn:
+		if len(values) == 0 {
+			errs = append(errs, field.Required(valuesPath, "values must be non-empty when operator is "+operator))
+		}
+	case v1.NodeSelectorOpExists, v1.NodeSelectorOpDoesNotExist:
+		if len(values) > 0 {
+			errs = append(errs, field.Forbidden(valuesPath, "values must be empty when operator is "+operator))
+		}
+	case v1.NodeSelectorOpGt, v1.NodeSelectorOpLt:
+		if len(values) != 1 {
+			errs = append(errs, field.Required(valuesPath, "exactly one value is required when operator is "+operator))
+		} else if _, err := strconv.ParseInt(values[0], 10, 64); err != nil {
+			errs = append(errs, field.Invalid(valuesPath.Index(0), values[0], "must be an integer when operator is "+operator))
+		}
+	default:
+		errs = append(errs, field.NotSupported(fldPath.Child("operator"), operator, []string{
+			string(v1.NodeSelectorOpIn), string(v1.NodeSelectorOpNotIn),
+			string(v1.NodeSelectorOpExists), string(v1.NodeSelectorOpDoesNotExist),
+			string(v1.NodeSelectorOpGt), string(v1.NodeSelectorOpLt),
+		}))
+	}
+	return errs
+}
+
+// validateScheduling checks the tolerations, the topology spread constraints and the pod anti-affinity
+func (s *SpringBoot) validateScheduling(fldPath *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	for i, toleration := range s.Tolerations {
+		tolerationPath := fldPath.Child("tolerations").Index(i)
+		switch toleration.Operator {
+		case "", v1.TolerationOpEqual:
+		case v1.TolerationOpExists:
+			if toleration.Value != "" {
+				errs = append(errs, field.Invalid(tolerationPath.Child("value"), toleration.Value, "must be empty when operator is Exists"))
+			}
+		default:
+			errs = append(errs, field.NotSupported(tolerationPath.Child("operator"), toleration.Operator,
+				[]string{string(v1.TolerationOpEqual), string(v1.TolerationOpExists)}))
+		}
+		if toleration.Key == "" && toleration.Operator != v1.TolerationOpExists {
+			errs = append(errs, field.Invalid(tolerationPath.Child("operator"), toleration.Operator, "must be Exists when key is empty"))
+		}
+		switch toleration.Effect {
+		case "", v1.TaintEffectNoSchedule, v1.TaintEffectPreferNoSchedule, v1.TaintEffectNoExecute:
+		default:
+			errs = append(errs, field.NotSupported(tolerationPath.Child("effect"), toleration.Effect, []string{
+				string(v1.TaintEffectNoSchedule), string(v1.TaintEffectPreferNoSchedule), string(v1.TaintEffectNoExecute),
+			}))
+		}
+		if toleration.TolerationSeconds != nil && toleration.Effect != v1.TaintEffectNoExecute {
+			errs = append(errs, field.Invalid(tolerationPath.Child("effect"), toleration.Effect, "must be NoExecute when tolerationSeconds is set"))
+		}
+	}
+	for i, constraint := range s.TopologySpreadConstraints {
+		constraintPath := fldPath.Child("topologySpreadConstraints").Index(i)
+		if constraint.MaxSkew < 1 {
+			errs = append(errs, field.Invalid(constraintPath.Child("maxSkew"), constraint.MaxSkew, "must be greater than 0"))
+		}
+		if constraint.TopologyKey == "" {
+			errs = append(errs, field.Required(constraintPath.Child("topologyKey"), "topologyKey is required"))
+		}
+		switch constraint.WhenUnsatisfiable {
+		case v1.DoNotSchedule, v1.ScheduleAnyway:
+		default:
+			errs = append(errs, field.NotSupported(constraintPath.Child("whenUnsatisfiable"), constraint.WhenUnsatisfiable,
+				[]string{string(v1.DoNotSchedule), string(v1.ScheduleAnyway)}))
+		}
+	}
+	switch s.PodAntiAffinity {
+	case "", PodAntiAffinitySoft, PodAntiAffinityHard, PodAntiAffinityOff:
+	default:
+		errs = append(errs, field.NotSupported(fldPath.Child("podAntiAffinity"), s.PodAntiAffinity,
+			[]string{PodAntiAffinitySoft, PodAntiAffinityHard, PodAntiAffinityOff}))
+	}
+	return errs
+}
+
+func (a *AutoscalingSpec) validate(fldPath *field.Path, springBoot *SpringBoot) field.ErrorList {
+	var errs field.ErrorList
+	if !a.Enabled {
+		return errs
+	}
+	if a.MaxReplicas == 0 {
+		errs = append(errs, field.Required(fldPath.Child("maxReplicas"), "maxReplicas is required when autoscaling is enabled"))
+	} else if a.MaxReplicas < 1 {
+		errs = append(errs, field.Invalid(fldPath.Child("maxReplicas"), a.MaxReplicas, "must be greater than or equal to 1"))
+	}
+	// the effective minimum, the replicas when minReplicas is not set
+	if minReplicas := springBoot.MinReplicas(); minReplicas < 1 {
+		errs = append(errs, field.Invalid(fldPath.Child("minReplicas"), minReplicas, "must be greater than or equal to 1"))
+	} else if a.MaxReplicas >= 1 && minReplicas > a.MaxReplicas {
+		errs = append(errs, field.Invalid(fldPath.Child("minReplicas"), minReplicas, "must be less than or equal to maxReplicas"))
+	}
+	if a.TargetCPUUtilizationPercentage != nil && *a.TargetCPUUtilizationPercentage < 1 {
+		errs = append(errs, field.Invalid(fldPath.Child("targetCPUUtilizationPercentage"), *a.TargetCPUUtilizationPercentage, "must be greater than 0"))
+	}
+	if a.TargetMemoryUtilizationPercentage != nil && *a.TargetMemoryUtilizationPercentage < 1 {
+		errs = append(errs, field.Invalid(fldPath.Child("targetMemoryUtilizationPercentage"), *a.TargetMemoryUtilizationPercentage, "must be greater than 0"))
+	}
+	return errs
+}
+
+func (p *PodDisruptionBudgetSpec) validate(fldPath *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	if p.MinAvailable != nil && p.MaxUnavailable != nil {
+		errs = append(errs, field.Forbidden(fldPath.Child("maxUnavailable"), "minAvailable and maxUnavailable are mutually exclusive"))
+	}
+	errs = append(errs, validateIntOrPercent(fldPath.Child("minAvailable"), p.MinAvailable)...)
+	errs = append(errs, validateIntOrPercent(fldPath.Child("maxUnavailable"), p.MaxUnavailable)...)
+	return errs
+}
+
+// validateIntOrPercent checks for a non negative number or a percentage between 0% and 100%
+func validateIntOrPercent(fldPath *field.Path, value *intstr.IntOrString) field.ErrorList {
+	if value == nil {
+		return nil
+	}
+	if value.Type == intstr.Int {
+		if value.IntVal < 0 {
+			return field.ErrorList{field.Invalid(fldPath, value.IntVal, "must be greater than or equal to 0")}
+		}
+		return nil
+	}
+	percent, err := strconv.Atoi(strings.TrimSuffix(value.StrVal, "%"))
+	if !strings.HasSuffix(value.StrVal, "%") || err != nil || percent < 0 || percent > 100 {
+		return field.ErrorList{field.Invalid(fldPath, value.StrVal, "must be a number or a percentage between 0% and 100%")}
+	}
+	return nil
+}
+
+func (i *IngressSpec) validate(fldPath *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	if !i.Enabled {
+		return errs
+	}
+	for index, host := range i.Hosts {
+		for _, msg := range validation.IsDNS1123Subdomain(strings.TrimPrefix(host, "*.")) {
+			errs = append(errs, field.Invalid(fldPath.Child("hosts").Index(index), host, msg))
+		}
+	}
+	for index, path := range i.Paths {
+		errs = append(errs, validatePath(fldPath.Child("paths").Index(index), path)...)
+	}
+	if i.TLSSecretName != "" && len(i.Hosts) == 0 {
+		errs = append(errs, field.Required(fldPath.Child("hosts"), "hosts are required when tlsSecretName is set"))
+	}
+	return errs
+}
+
+func (t *ServiceSpec) validate(fldPath *field.Path, s *SpringBoot) field.ErrorList {
+	var errs field.ErrorList
+	exposesNodePorts := t.Type == ServiceTypeNodePort || t.Type == ServiceTypeLoadBalancer
+	switch t.Type {
+	case "", ServiceTypeClusterIP, ServiceTypeNodePort, ServiceTypeLoadBalancer:
+	case ServiceTypeHeadless:
+		if s.ClusterIp != "" && s.ClusterIp != v1.ClusterIPNone {
+			errs = append(errs, field.Forbidden(fldPath.Child("type"), "a Headless service can not have the cluster ip "+s.ClusterIp))
+		}
+	default:
+		errs = append(errs, field.NotSupported(fldPath.Child("type"), t.Type, []string{
+			ServiceTypeClusterIP, ServiceTypeNodePort, ServiceTypeLoadBalancer, ServiceTypeHeadless,
+		}))
+	}
+	errs = append(errs, validateNodePort(fldPath.Child("nodePort"), t.NodePort, exposesNodePorts)...)
+
+	switch t.SessionAffinity {
+	case "", v1.ServiceAffinityNone, v1.ServiceAffinityClientIP:
+	default:
+		errs = append(errs, field.NotSupported(fldPath.Child("sessionAffinity"), t.SessionAffinity, []string{
+			string(v1.ServiceAffinityNone), string(v1.ServiceAffinityClientIP),
+		}))
+	}
+	switch t.ExternalTrafficPolicy {
+	case "":
+	case v1.ServiceExternalTrafficPolicyTypeCluster, v1.ServiceExternalTrafficPolicyTypeLocal:
+		if !exposesNodePorts {
+			errs = append(errs, field.Forbidden(fldPath.Child("externalTrafficPolicy"), "only NodePort and LoadBalancer services have an external traffic policy"))
+		}
+	default:
+		errs = append(errs, field.NotSupported(fldPath.Child("externalTrafficPolicy"), t.ExternalTrafficPolicy, []string{
+			string(v1.ServiceExternalTrafficPolicyTypeCluster), string(v1.ServiceExternalTrafficPolicyTypeLocal),
+		}))
+	}
+
+	names := map[string]bool{}
+	// the application port is the first service port, its name is checked by ValidateServicePorts
+	ports := map[v1.ServicePort]bool{}
+	if s.Port != 0 {
+		ports[v1.ServicePort{Port: s.Port, Protocol: v1.ProtocolTCP}] = true
+	}
+	for i, port := range t.Ports {
+		portPath := fldPath.Child("ports").Index(i)
+		for _, msg := range validation.IsDNS1123Label(port.Name) {
+			errs = append(errs, field.Invalid(portPath.Child("name"), port.Name, msg))
+		}
+		if names[port.Name] {
+			errs = append(errs, field.Duplicate(portPath.Child("name"), port.Name))
+		}
+		names[port.Name] = true
+		for _, msg := range validation.IsValidPortNum(int(port.Port)) {
+			errs = append(errs, field.Invalid(portPath.Child("port"), port.Port, msg))
+		}
+		protocol := port.Protocol
+		if protocol == "" {
+			protocol = v1.ProtocolTCP
+		}
+		if key := (v1.ServicePort{Port: port.Port, Protocol: protocol}); ports[key] {
+			errs = append(errs, field.Duplicate(portPath.Child("port"), port.Port))
+		} else {
+			ports[key] = true
+		}
+		if port.TargetPort.Type == intstr.String && port.TargetPort.StrVal != "" {
+			for _, msg := range validation.IsValidPortName(port.TargetPort.StrVal) {
+				errs = append(errs, field.Invalid(portPath.Child("targetPort"), port.TargetPort.StrVal, msg))
+			}
+		} else if port.TargetPort.IntVal != 0 {
+			for _, msg := range validation.IsValidPortNum(int(port.TargetPort.IntVal)) {
+				errs = append(errs, field.Invalid(portPath.Child("targetPort"), port.TargetPort.IntVal, msg))
+			}
+		}
+		errs = append(errs, validateNodePort(portPath.Child("nodePort"), port.NodePort, exposesNodePorts)...)
+		switch port.Protocol {
+		case "", v1.ProtocolTCP, v1.ProtocolUDP, v1.ProtocolSCTP:
+		default:
+			errs = append(errs, field.NotSupported(portPath.Child("protocol"), port.Protocol, []string{
+				string(v1.ProtocolTCP), string(v1.ProtocolUDP), string(v1.ProtocolSCTP),
+			}))
+		}
+	}
+	return errs
+}
+
+func validateNodePort(fldPath *field.Path, nodePort int32, exposesNodePorts bool) field.ErrorList {
+	if nodePort == 0 {
+		return nil
+	}
+	if !exposesNodePorts {
+		return field.ErrorList{field.Forbidden(fldPath, "only NodePort and LoadBalancer services have node ports")}
+	}
+	var errs field.ErrorList
+	for _, msg := range validation.IsValidPortNum(int(nodePort)) {
+		errs = append(errs, field.Invalid(fldPath, nodePort, msg))
+	}
+	return errs
+}
+
+func (c *ConfigSpec) validate(fldPath *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	if c.ConfigMapName != "" && len(c.Files) > 0 {
+		errs = append(errs, field.Forbidden(fldPath.Child("files"), "files and configMapName are mutually exclusive"))
+	}
+	if c.ConfigMapName != "" {
+		for _, msg := range validation.IsDNS1123Subdomain(c.ConfigMapName) {
+			errs = append(errs, field.Invalid(fldPath.Child("configMapName"), c.ConfigMapName, msg))
+		}
+	}
+	for name := range c.Files {
+		for _, msg := range validation.IsConfigMapKey(name) {
+			errs = append(errs, field.Invalid(fldPath.Child("files").Key(name), name, msg))
+		}
+	}
+	return errs
+}
+
+func (secret *SecretSpec) validate(fldPath *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	for _, msg := range validation.IsDNS1123Subdomain(secret.Name) {
+		errs = append(errs, field.Invalid(fldPath.Child("name"), secret.Name, msg))
+	}
+	if secret.MountPath != "" {
+		errs = append(errs, validatePath(fldPath.Child("mountPath"), secret.MountPath)...)
+		if secret.EnvPrefix != "" {
+			errs = append(errs, field.Forbidden(fldPath.Child("envPrefix"), "envPrefix is only used when the secret is injected as env"))
+		}
+	}
+	if secret.EnvPrefix != "" {
+		for _, msg := range validation.IsEnvVarName(secret.EnvPrefix) {
+			errs = append(errs, field.Invalid(fldPath.Child("envPrefix"), secret.EnvPrefix, msg))
+		}
+	}
+	return errs
+}
+
+func (p *ProbesSpec) validate(fldPath *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	errs = append(errs, p.Liveness.validate(fldPath.Child("liveness"))...)
+	errs = append(errs, p.Readiness.validate(fldPath.Child("readiness"))...)
+	if p.Startup != nil {
+		errs = append(errs, p.Startup.validate(fldPath.Child("startup"))...)
+	}
+	return errs
+}
+
+func (p *ProbeSpec) validate(fldPath *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	if p.InitialDelaySeconds < 0 {
+		errs = append(errs, field.Invalid(fldPath.Child("initialDelaySeconds"), p.InitialDelaySeconds, "must be greater than or equal to 0"))
+	}
+	if p.PeriodSeconds < 0 {
+		errs = append(errs, field.Invalid(fldPath.Child("periodSeconds"), p.PeriodSeconds, "must be greater than 0"))
+	}
+	if p.TimeoutSeconds < 0 {
+		errs = append(errs, field.Invalid(fldPath.Child("timeoutSeconds"), p.TimeoutSeconds, "must be greater than 0"))
+	}
+	if p.FailureThreshold < 0 {
+		errs = append(errs, field.Invalid(fldPath.Child("failureThreshold"), p.FailureThreshold, "must be greater than 0"))
+	}
+	return errs
+}
+
+func (j *JvmSpec) validate(fldPath *field.Path, s *SpringBoot) field.ErrorList {
+	var errs field.ErrorList
+	if j.HeapPercentage < 0 || j.HeapPercentage > 100 {
+		errs = append(errs, field.Invalid(fldPath.Child("heapPercentage"), j.HeapPercentage, "must be between 1 and 100"))
+	} else if limit, err := parseQuantity(s.Resource.Memory.Limit); err == nil && limit != nil && !limit.IsZero() {
+		heapPercentage := int64(j.HeapPercentage)
+		if heapPercentage == 0 {
+			heapPercentage = DefaultHeapPercentage
+		}
+		if limit.Value()*heapPercentage/100 < MinHeapSize {
+			errs = append(errs, field.Invalid(fldPath.Child("heapPercentage"), heapPercentage,
+				"leaves a heap below 2Mi of the memory limit "+s.Resource.Memory.Limit))
+		}
+	}
+	if metaspace, err := parseQuantity(j.MaxMetaspaceSize); err != nil {
+		errs = append(errs, field.Invalid(fldPath.Child("maxMetaspaceSize"), j.MaxMetaspaceSize, err.Error()))
+	} else if metaspace != nil && metaspace.Value() < MinMetaspaceSize {
+		errs = append(errs, field.Invalid(fldPath.Child("maxMetaspaceSize"), j.MaxMetaspaceSize, "must be at least 1Mi"))
+	}
+	switch j.GC {
+	case "", GCG1, GCParallel, GCSerial, GCShenandoah, GCZ:
+	default:
+		errs = append(errs, field.NotSupported(fldPath.Child("gc"), j.GC, []string{GCG1, GCParallel, GCSerial, GCShenandoah, GCZ}))
+	}
+	errs = append(errs, validatePath(fldPath.Child("heapDumpPath"), j.HeapDumpPath)...)
+	return errs
+}
+
+func (sh *ShutdownSpec) validate(fldPath *field.Path, s *SpringBoot) field.ErrorList {
+	var errs field.ErrorList
+	switch sh.Mode {
+	case "", ShutdownModeHTTPGet, ShutdownModeSleep, ShutdownModeNone:
+	default:
+		errs = append(errs, field.NotSupported(fldPath.Child("mode"), sh.Mode,
+			[]string{ShutdownModeHTTPGet, ShutdownModeSleep, ShutdownModeNone}))
+	}
+	if sh.SleepSeconds < 0 {
+		errs = append(errs, field.Invalid(fldPath.Child("sleepSeconds"), sh.SleepSeconds, "must be greater than 0"))
+	}
+	if sh.GracefulTimeoutSeconds < 0 {
+		errs = append(errs, field.Invalid(fldPath.Child("gracefulTimeoutSeconds"), sh.GracefulTimeoutSeconds, "must be greater than 0"))
+	}
+	if sh.TerminationGracePeriodSeconds != nil {
+		required := int64(sh.GracefulTimeoutSeconds)
+		if sh.Mode == ShutdownModeSleep {
+			required += int64(s.ShutdownSleepSeconds())
+		}
+		if *sh.TerminationGracePeriodSeconds < 0 {
+			errs = append(errs, field.Invalid(fldPath.Child("terminationGracePeriodSeconds"),
+				*sh.TerminationGracePeriodSeconds, "must be greater than or equal to 0"))
+		} else if *sh.TerminationGracePeriodSeconds < required {
+			errs = append(errs, field.Invalid(fldPath.Child("terminationGracePeriodSeconds"), *sh.TerminationGracePeriodSeconds,
+				fmt.Sprintf("must be at least the preStop sleep plus the graceful timeout, %d seconds", required)))
+		}
+	}
+	return errs
+}
+
+func (sc *SecuritySpec) validate(fldPath *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	if sc.ServiceAccountName != "" {
+		for _, msg := range validation.IsDNS1123Subdomain(sc.ServiceAccountName) {
+			errs = append(errs, field.Invalid(fldPath.Child("serviceAccountName"), sc.ServiceAccountName, msg))
+		}
+	}
+	if sc.RunAsUser != nil && *sc.RunAsUser < 0 {
+		errs = append(errs, field.Invalid(fldPath.Child("runAsUser"), *sc.RunAsUser, "must be greater than or equal to 0"))
+	}
+	if sc.RunAsNonRoot != nil && *sc.RunAsNonRoot && sc.RunAsUser != nil && *sc.RunAsUser == 0 {
+		errs = append(errs, field.Invalid(fldPath.Child("runAsUser"), *sc.RunAsUser, "must not be 0 when runAsNonRoot is true"))
+	}
+	if sc.FSGroup != nil && *sc.FSGroup < 0 {
+		errs = append(errs, field.Invalid(fldPath.Child("fsGroup"), *sc.FSGroup, "must be greater than or equal to 0"))
+	}
+	var capabilities []v1.Capability
+	if sc.DropCapabilities != nil {
+		capabilities = *sc.DropCapabilities
+	}
+	for i, capability := range capabilities {
+		if capability == "" {
+			errs = append(errs, field.Required(fldPath.Child("dropCapabilities").Index(i), "capability must not be empty"))
+		}
+	}
+	profilePath := fldPath.Child("seccompProfile")
+	switch {
+	case sc.SeccompProfile == "", sc.SeccompProfile == v1.SeccompProfileRuntimeDefault,
+		sc.SeccompProfile == v1.DeprecatedSeccompProfileDockerDefault, sc.SeccompProfile == "unconfined":
+	case strings.HasPrefix(sc.SeccompProfile, seccompLocalhostPrefix):
+		if sc.SeccompProfile == seccompLocalhostPrefix {
+			errs = append(errs, field.Invalid(profilePath, sc.SeccompProfile, "must name the localhost profile"))
+		}
+	default:
+		errs = append(errs, field.NotSupported(profilePath, sc.SeccompProfile, []string{
+			v1.SeccompProfileRuntimeDefault, "unconfined", seccompLocalhostPrefix + "<profile>",
+		}))
+	}
+	return errs
+}
+
+// seccompLocalhostPrefix prefixes a profile installed on the nodes
+const seccompLocalhostPrefix = "localhost/"
+
+// validateContainers checks that the init and sidecar containers are named uniquely within the pod
+func (s *SpringBoot) validateContainers(fldPath *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	// the application container name is checked by ValidateContainerNames
+	names := map[string]bool{}
+	lists := []struct {
+		name       string
+		containers []v1.Container
+	}{
+		{"initContainers", s.InitContainers},
+		{"sidecars", s.Sidecars},
+	}
+	for _, list := range lists {
+		for i, container := range list.containers {
+			containerPath := fldPath.Child(list.name).Index(i)
+			if container.Name == "" {
+				errs = append(errs, field.Required(containerPath.Child("name"), "name is required"))
+			} else if names[container.Name] {
+				errs = append(errs, field.Duplicate(containerPath.Child("name"), container.Name))
+			} else {
+				for _, msg := range validation.IsDNS1123Label(container.Name) {
+					errs = append(errs, field.Invalid(containerPath.Child("name"), container.Name, msg))
+				}
+			}
+			names[container.Name] = true
+			if container.Image == "" {
+				errs = append(errs, field.Required(containerPath.Child("image"), "image is required"))
+			}
+		}
+	}
+	return errs
+}
+
+// ValidateContainerNames checks that no init or sidecar container takes the name of
+// the application container, which is named after the application
+func (s *SpringBoot) ValidateContainerNames(fldPath *field.Path, name string) field.ErrorList {
+	var errs field.ErrorList
+	for i, container := range s.InitContainers {
+		if container.Name == name {
+			errs = append(errs, field.Duplicate(fldPath.Child("initContainers").Index(i).Child("name"), container.Name))
+		}
+	}
+	for i, container := range s.Sidecars {
+		if container.Name == name {
+			errs = append(errs, field.Duplicate(fldPath.Child("sidecars").Index(i).Child("name"), container.Name))
+		}
+	}
+	return errs
+}
+
+// ValidateServicePorts checks that no extra service port takes the name of the
+// application port, which is named after the application
+func (s *SpringBoot) ValidateServicePorts(fldPath *field.Path, name string) field.ErrorList {
+	var errs field.ErrorList
+	for i, port := range s.Service.Ports {
+		if port.Name == name {
+			errs = append(errs, field.Duplicate(fldPath.Child("service", "ports").Index(i).Child("name"), port.Name))
+		}
+	}
+	return errs
+}
+
+// ReservedVolumeNames are the names of the volumes generated by the operator,
+// volumes of the mounted secrets are named 'secret-<index>'
+var ReservedVolumeNames = []string{"applogpath", "config", "tmp"}
+
+func (s *SpringBoot) validateVolumes(fldPath *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	switch s.LogVolume {
+	case "", LogVolumeHostPath, LogVolumeEmptyDir, LogVolumeNone:
+	default:
+		errs = append(errs, field.NotSupported(fldPath.Child("logVolume"), s.LogVolume,
+			[]string{LogVolumeHostPath, LogVolumeEmptyDir, LogVolumeNone}))
+	}
+
+	names := map[string]bool{}
+	for _, name := range ReservedVolumeNames {
+		names[name] = true
+	}
+	for i, volume := range s.Volumes {
+		namePath := fldPath.Child("volumes").Index(i).Child("name")
+		switch {
+		case volume.Name == "":
+			errs = append(errs, field.Required(namePath, "name is required"))
+		case names[volume.Name] || strings.HasPrefix(volume.Name, "secret-"):
+			errs = append(errs, field.Invalid(namePath, volume.Name, "must not be one of the generated volumes "+
+				strings.Join(ReservedVolumeNames, ", ")+" or start with 'secret-'"))
+		default:
+			for _, msg := range validation.IsDNS1123Label(volume.Name) {
+				errs = append(errs, field.Invalid(namePath, volume.Name, msg))
+			}
+		}
+		names[volume.Name] = true
+	}
+	mountable := map[string]bool{}
+	for _, volume := range s.Volumes {
+		mountable[volume.Name] = true
+	}
+	mountPaths := map[string]bool{}
+	for name, path := range s.generatedMounts() {
+		mountable[name] = true
+		if path != "" {
+			mountPaths[filepath.Clean(path)] = true
+		}
+	}
+	for i, mount := range s.VolumeMounts {
+		mountPath := fldPath.Child("volumeMounts").Index(i)
+		if mount.Name == "" {
+			errs = append(errs, field.Required(mountPath.Child("name"), "name is required"))
+		} else if !mountable[mount.Name] {
+			errs = append(errs, field.NotFound(mountPath.Child("name"), mount.Name))
+		}
+		if mount.MountPath == "" {
+			errs = append(errs, field.Required(mountPath.Child("mountPath"), "mountPath is required"))
+			continue
+		}
+		errs = append(errs, validatePath(mountPath.Child("mountPath"), mount.MountPath)...)
+		if path := filepath.Clean(mount.MountPath); mountPaths[path] {
+			errs = append(errs, field.Duplicate(mountPath.Child("mountPath"), mount.MountPath))
+		} else {
+			mountPaths[path] = true
+		}
+	}
+	return errs
+}
+
+// generatedMounts returns the mount paths of the volumes the operator may generate, by volume name.
+// The path is empty while it depends on a default, e.g. the unset log path
+func (s *SpringBoot) generatedMounts() map[string]string {
+	mounts := map[string]string{}
+	if s.LogVolume != LogVolumeNone {
+		mounts["applogpath"] = s.Path.HostLog
+	}
+	if len(s.Config.Files) > 0 || s.Config.ConfigMapName != "" {
+		mounts["config"] = ConfigMountPath
+	}
+	if readOnly := s.Security.ReadOnlyRootFilesystem; readOnly == nil {
+		mounts["tmp"] = ""
+	} else if *readOnly {
+		mounts["tmp"] = "/tmp"
+	}
+	for i, secret := range s.Secrets {
+		if secret.MountPath != "" {
+			mounts[fmt.Sprintf("secret-%d", i)] = secret.MountPath
+		}
+	}
+	return mounts
+}
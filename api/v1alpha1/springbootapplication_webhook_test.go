@@ -0,0 +1,111 @@
+/*
+Copyright 2020 qingmu.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+func TestValidateServicePorts(t *testing.T) {
+	tests := []struct {
+		name  string
+		ports []ServicePortSpec
+		valid bool
+	}{
+		{"extra port", []ServicePortSpec{{Name: "grpc", Port: 9090}}, true},
+		{"application port name", []ServicePortSpec{{Name: "demo", Port: 9090}}, false},
+		{"application port number", []ServicePortSpec{{Name: "grpc", Port: 8080}}, false},
+		{"application port number over udp", []ServicePortSpec{{Name: "dns", Port: 8080, Protocol: v1.ProtocolUDP}}, true},
+		{"duplicated extra port number", []ServicePortSpec{{Name: "grpc", Port: 9090}, {Name: "grpcs", Port: 9090, Protocol: v1.ProtocolTCP}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			springBoot := &SpringBoot{Port: 8080, Service: ServiceSpec{Ports: tt.ports}}
+			fldPath := field.NewPath("spec", "springBoot")
+			errs := springBoot.Service.validate(fldPath.Child("service"), springBoot)
+			errs = append(errs, springBoot.ValidateServicePorts(fldPath, "demo")...)
+			if valid := len(errs) == 0; valid != tt.valid {
+				t.Errorf("validate() = %v, want valid %v", errs, tt.valid)
+			}
+		})
+	}
+}
+
+func TestValidateJvm(t *testing.T) {
+	tests := []struct {
+		name        string
+		jvm         JvmSpec
+		memoryLimit string
+		valid       bool
+	}{
+		{"defaults", JvmSpec{}, "512Mi", true},
+		{"unlimited memory", JvmSpec{HeapPercentage: 1}, "", true},
+		{"heap below the jvm minimum", JvmSpec{HeapPercentage: 1}, "100Mi", false},
+		{"metaspace", JvmSpec{MaxMetaspaceSize: "256Mi"}, "512Mi", true},
+		{"metaspace below 1Mi", JvmSpec{MaxMetaspaceSize: "512Ki"}, "512Mi", false},
+		{"invalid metaspace", JvmSpec{MaxMetaspaceSize: "lots"}, "512Mi", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			springBoot := &SpringBoot{Jvm: &tt.jvm, Resource: ResourceSpec{Memory: MemorySpec{Limit: tt.memoryLimit}}}
+			errs := springBoot.Jvm.validate(field.NewPath("jvm"), springBoot)
+			if valid := len(errs) == 0; valid != tt.valid {
+				t.Errorf("validate() = %v, want valid %v", errs, tt.valid)
+			}
+		})
+	}
+}
+
+func TestValidateVolumeMounts(t *testing.T) {
+	readOnly := true
+	data := v1.Volume{Name: "data", VolumeSource: v1.VolumeSource{EmptyDir: &v1.EmptyDirVolumeSource{}}}
+	tests := []struct {
+		name   string
+		mounts []v1.VolumeMount
+		valid  bool
+	}{
+		{"volume", []v1.VolumeMount{{Name: "data", MountPath: "/data"}}, true},
+		{"generated volume", []v1.VolumeMount{{Name: "applogpath", MountPath: "/logs"}}, true},
+		{"secret volume", []v1.VolumeMount{{Name: "secret-0", MountPath: "/db"}}, true},
+		{"unknown volume", []v1.VolumeMount{{Name: "missing", MountPath: "/data"}}, false},
+		{"secret volume not mounted", []v1.VolumeMount{{Name: "secret-1", MountPath: "/data"}}, false},
+		{"log path", []v1.VolumeMount{{Name: "data", MountPath: "/var/applog/"}}, false},
+		{"config path", []v1.VolumeMount{{Name: "data", MountPath: ConfigMountPath}}, false},
+		{"tmp path", []v1.VolumeMount{{Name: "data", MountPath: "/tmp"}}, false},
+		{"secret path", []v1.VolumeMount{{Name: "data", MountPath: "/secrets/db"}}, false},
+		{"duplicated path", []v1.VolumeMount{{Name: "data", MountPath: "/data"}, {Name: "data", MountPath: "/data"}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			springBoot := &SpringBoot{
+				Path:         PathSpec{HostLog: "/var/applog"},
+				Config:       ConfigSpec{Files: map[string]string{"application.yml": ""}},
+				Security:     SecuritySpec{ReadOnlyRootFilesystem: &readOnly},
+				Secrets:      []SecretSpec{{Name: "db", MountPath: "/secrets/db"}, {Name: "api"}},
+				Volumes:      []v1.Volume{data},
+				VolumeMounts: tt.mounts,
+			}
+			errs := springBoot.validateVolumes(field.NewPath("spec", "springBoot"))
+			if valid := len(errs) == 0; valid != tt.valid {
+				t.Errorf("validateVolumes() = %v, want valid %v", errs, tt.valid)
+			}
+		})
+	}
+}
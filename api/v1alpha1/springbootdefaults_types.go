@@ -0,0 +1,227 @@
+/*
+Copyright 2020 qingmu.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"spring-boot-operator/global"
+)
+
+// SpringBootDefaultsSpec defines the defaults of the applications in the namespace.
+// The fields mirror the operator configuration, a set value overrides the operator default
+type SpringBootDefaultsSpec struct {
+	// The image repository, the image is fmt.Sprintf("%s/%s:%s", ImageRepository, Name, Version)
+	ImageRepository string `json:"imageRepository,omitempty"`
+	RequestCpu      string `json:"requestCpu,omitempty"`
+	LimitCpu        string `json:"limitCpu,omitempty"`
+	RequestMemory   string `json:"requestMemory,omitempty"`
+	LimitMemory     string `json:"limitMemory,omitempty"`
+	LivenessPath    string `json:"livenessPath,omitempty"`
+	ReadinessPath   string `json:"readinessPath,omitempty"`
+	HostLogPath     string `json:"hostLogPath,omitempty"`
+	ShutdownPath    string `json:"shutdownPath,omitempty"`
+	Replicas        int32  `json:"replicas,omitempty"`
+	Port            int32  `json:"port,omitempty"`
+	// The env added to every application, merged with the operator env by name
+	Env []v1.EnvVar `json:"env,omitempty" patchStrategy:"merge" patchMergeKey:"name"`
+	// The pull secrets, replacing the operator ones
+	ImagePullSecrets []string `json:"imagePullSecrets,omitempty"`
+	// e.g. failure-domain.beta.kubernetes.io/zone
+	NodeAffinityKey      string   `json:"nodeAffinityKey,omitempty"`
+	NodeAffinityValues   []string `json:"nodeAffinityValues,omitempty"`
+	NodeAffinityOperator string   `json:"nodeAffinityOperator,omitempty"`
+	// The pod security settings
+	RunAsNonRoot                 *bool     `json:"runAsNonRoot,omitempty"`
+	RunAsUser                    *int64    `json:"runAsUser,omitempty"`
+	FSGroup                      *int64    `json:"fsGroup,omitempty"`
+	ReadOnlyRootFilesystem       *bool     `json:"readOnlyRootFilesystem,omitempty"`
+	AutomountServiceAccountToken *bool     `json:"automountServiceAccountToken,omitempty"`
+	DropCapabilities             *[]string `json:"dropCapabilities,omitempty"`
+	SeccompProfile               string    `json:"seccompProfile,omitempty"`
+	// The scheduling of the pods
+	Tolerations               []v1.Toleration               `json:"tolerations,omitempty"`
+	NodeSelector              map[string]string             `json:"nodeSelector,omitempty"`
+	TopologySpreadConstraints []v1.TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
+	// Soft, Hard or Off
+	PodAntiAffinity string `json:"podAntiAffinity,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:shortName=sbd
+
+// SpringBootDefaults is the Schema for the springbootdefaults API.
+// The defaults of every SpringBootDefaults in a namespace apply to its applications,
+// in the order of their names when several set the same field
+type SpringBootDefaults struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec SpringBootDefaultsSpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// SpringBootDefaultsList contains a list of SpringBootDefaults
+type SpringBootDefaultsList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []SpringBootDefaults `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&SpringBootDefaults{}, &SpringBootDefaultsList{})
+}
+
+// operatorDefaults returns the operator configuration as defaults
+func operatorDefaults() *SpringBootDefaultsSpec {
+	config := global.GetGlobalConfig()
+	return &SpringBootDefaultsSpec{
+		ImageRepository:              config.ImageRepository,
+		RequestCpu:                   config.RequestCpu,
+		LimitCpu:                     config.LimitCpu,
+		RequestMemory:                config.RequestMemory,
+		LimitMemory:                  config.LimitMemory,
+		LivenessPath:                 config.LivenessPath,
+		ReadinessPath:                config.ReadinessPath,
+		HostLogPath:                  config.HostLogPath,
+		ShutdownPath:                 config.ShutdownPath,
+		Replicas:                     config.Replicas,
+		Port:                         config.Port,
+		Env:                          config.Env,
+		ImagePullSecrets:             config.ImagePullSecrets,
+		NodeAffinityKey:              config.NodeAffinityKey,
+		NodeAffinityValues:           config.NodeAffinityValues,
+		NodeAffinityOperator:         config.NodeAffinityOperator,
+		RunAsNonRoot:                 config.RunAsNonRoot,
+		RunAsUser:                    config.RunAsUser,
+		FSGroup:                      config.FSGroup,
+		ReadOnlyRootFilesystem:       config.ReadOnlyRootFilesystem,
+		AutomountServiceAccountToken: config.AutomountServiceAccountToken,
+		DropCapabilities:             dropCapabilities(config.DropCapabilities),
+		SeccompProfile:               config.SeccompProfile,
+		Tolerations:                  config.Tolerations,
+		NodeSelector:                 config.NodeSelector,
+		TopologySpreadConstraints:    config.TopologySpreadConstraints,
+		PodAntiAffinity:              config.PodAntiAffinity,
+	}
+}
+
+// dropCapabilities returns the capabilities dropped by the operator configuration, nil when it drops none
+func dropCapabilities(capabilities []string) *[]string {
+	if len(capabilities) == 0 {
+		return nil
+	}
+	return &capabilities
+}
+
+// effectiveDefaults returns the operator defaults overridden by the namespace defaults, in order
+func effectiveDefaults(namespaceDefaults []*SpringBootDefaultsSpec) *SpringBootDefaultsSpec {
+	defaults := operatorDefaults()
+	for _, d := range namespaceDefaults {
+		defaults.merge(d)
+	}
+	return defaults
+}
+
+// merge overrides the defaults with the values set in other. The env is merged by name,
+// lists and the node selector are replaced
+func (d *SpringBootDefaultsSpec) merge(other *SpringBootDefaultsSpec) {
+	values := []struct {
+		value    *string
+		override string
+	}{
+		{&d.ImageRepository, other.ImageRepository},
+		{&d.RequestCpu, other.RequestCpu},
+		{&d.LimitCpu, other.LimitCpu},
+		{&d.RequestMemory, other.RequestMemory},
+		{&d.LimitMemory, other.LimitMemory},
+		{&d.LivenessPath, other.LivenessPath},
+		{&d.ReadinessPath, other.ReadinessPath},
+		{&d.HostLogPath, other.HostLogPath},
+		{&d.ShutdownPath, other.ShutdownPath},
+		{&d.SeccompProfile, other.SeccompProfile},
+		{&d.PodAntiAffinity, other.PodAntiAffinity},
+	}
+	for _, v := range values {
+		if v.override != "" {
+			*v.value = v.override
+		}
+	}
+	if other.Replicas != 0 {
+		d.Replicas = other.Replicas
+	}
+	if other.Port != 0 {
+		d.Port = other.Port
+	}
+	if len(other.Env) > 0 {
+		d.Env = overrideEnv(d.Env, other.Env)
+	}
+	if other.ImagePullSecrets != nil {
+		d.ImagePullSecrets = other.ImagePullSecrets
+	}
+	if other.NodeAffinityKey != "" {
+		d.NodeAffinityKey = other.NodeAffinityKey
+		d.NodeAffinityOperator = other.NodeAffinityOperator
+		d.NodeAffinityValues = other.NodeAffinityValues
+	}
+	if other.RunAsNonRoot != nil {
+		d.RunAsNonRoot = other.RunAsNonRoot
+	}
+	if other.RunAsUser != nil {
+		d.RunAsUser = other.RunAsUser
+	}
+	if other.FSGroup != nil {
+		d.FSGroup = other.FSGroup
+	}
+	if other.ReadOnlyRootFilesystem != nil {
+		d.ReadOnlyRootFilesystem = other.ReadOnlyRootFilesystem
+	}
+	if other.AutomountServiceAccountToken != nil {
+		d.AutomountServiceAccountToken = other.AutomountServiceAccountToken
+	}
+	if other.DropCapabilities != nil {
+		d.DropCapabilities = other.DropCapabilities
+	}
+	if other.Tolerations != nil {
+		d.Tolerations = other.Tolerations
+	}
+	if other.NodeSelector != nil {
+		d.NodeSelector = other.NodeSelector
+	}
+	if other.TopologySpreadConstraints != nil {
+		d.TopologySpreadConstraints = other.TopologySpreadConstraints
+	}
+}
+
+// ListNamespaceDefaults returns the SpringBootDefaults of the namespace in the order of their names
+func ListNamespaceDefaults(ctx context.Context, reader client.Reader, namespace string) ([]*SpringBootDefaultsSpec, error) {
+	list := &SpringBootDefaultsList{}
+	if err := reader.List(ctx, list, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+	sort.Slice(list.Items, func(i, j int) bool { return list.Items[i].Name < list.Items[j].Name })
+	defaults := make([]*SpringBootDefaultsSpec, 0, len(list.Items))
+	for i := range list.Items {
+		defaults = append(defaults, &list.Items[i].Spec)
+	}
+	return defaults, nil
+}
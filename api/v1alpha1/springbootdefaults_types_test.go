@@ -0,0 +1,183 @@
+/*
+Copyright 2020 qingmu.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"spring-boot-operator/global"
+)
+
+func TestDefaultsPrecedence(t *testing.T) {
+	global.GetGlobalConfig().ImageRepository = "global.example.com"
+	global.GetGlobalConfig().Port = 8080
+	global.GetGlobalConfig().Replicas = 3
+	global.GetGlobalConfig().RequestCpu = "50m"
+	defer func() {
+		global.GetGlobalConfig().ImageRepository = ""
+		global.GetGlobalConfig().Port = 0
+		global.GetGlobalConfig().Replicas = 0
+		global.GetGlobalConfig().RequestCpu = ""
+	}()
+
+	tests := []struct {
+		name              string
+		springBoot        SpringBoot
+		namespaceDefaults []SpringBootDefaults
+		wantImage         string
+		wantPort          int32
+		wantReplicas      int32
+		wantEnv           []string
+	}{
+		{
+			name:         "global",
+			wantImage:    "global.example.com/demo:v1",
+			wantPort:     8080,
+			wantReplicas: 3,
+		},
+		{
+			name: "namespace over global",
+			namespaceDefaults: []SpringBootDefaults{
+				{ObjectMeta: metav1.ObjectMeta{Name: "team"}, Spec: SpringBootDefaultsSpec{ImageRepository: "team.example.com", Port: 9090}},
+			},
+			wantImage:    "team.example.com/demo:v1",
+			wantPort:     9090,
+			wantReplicas: 3,
+		},
+		{
+			name: "later name over earlier name",
+			namespaceDefaults: []SpringBootDefaults{
+				{ObjectMeta: metav1.ObjectMeta{Name: "b-override"}, Spec: SpringBootDefaultsSpec{Port: 9191, Env: env("LEVEL", "b")}},
+				{ObjectMeta: metav1.ObjectMeta{Name: "a-base"}, Spec: SpringBootDefaultsSpec{Port: 9090, Replicas: 2, Env: env("LEVEL", "a", "TEAM", "a")}},
+			},
+			wantImage:    "global.example.com/demo:v1",
+			wantPort:     9191,
+			wantReplicas: 2,
+			wantEnv:      []string{"LEVEL=b", "TEAM=a"},
+		},
+		{
+			name:       "application over namespace",
+			springBoot: SpringBoot{Port: 7070, Image: "app.example.com/demo:v1", Env: env("LEVEL", "app")},
+			namespaceDefaults: []SpringBootDefaults{
+				{ObjectMeta: metav1.ObjectMeta{Name: "team"}, Spec: SpringBootDefaultsSpec{Port: 9090, Replicas: 2, Env: env("LEVEL", "team")}},
+			},
+			wantImage:    "app.example.com/demo:v1",
+			wantPort:     7070,
+			wantReplicas: 2,
+			wantEnv:      []string{"LEVEL=app"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheme := runtime.NewScheme()
+			if err := AddToScheme(scheme); err != nil {
+				t.Fatal(err)
+			}
+			var objects []runtime.Object
+			for i := range tt.namespaceDefaults {
+				defaults := tt.namespaceDefaults[i].DeepCopy()
+				defaults.Namespace = "default"
+				objects = append(objects, defaults)
+			}
+			namespaceDefaults, err := ListNamespaceDefaults(context.Background(), fake.NewFakeClientWithScheme(scheme, objects...), "default")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			springBoot := tt.springBoot.DeepCopy()
+			springBoot.Version = "v1"
+			if _, err := springBoot.Check("demo", namespaceDefaults...); err != nil {
+				t.Fatal(err)
+			}
+			if springBoot.Image != tt.wantImage {
+				t.Errorf("image = %s, want %s", springBoot.Image, tt.wantImage)
+			}
+			if springBoot.Port != tt.wantPort {
+				t.Errorf("port = %d, want %d", springBoot.Port, tt.wantPort)
+			}
+			if springBoot.Replicas != tt.wantReplicas {
+				t.Errorf("replicas = %d, want %d", springBoot.Replicas, tt.wantReplicas)
+			}
+			var gotEnv []string
+			for _, env := range springBoot.Env {
+				gotEnv = append(gotEnv, env.Name+"="+env.Value)
+			}
+			if !reflect.DeepEqual(gotEnv, tt.wantEnv) {
+				t.Errorf("env = %v, want %v", gotEnv, tt.wantEnv)
+			}
+		})
+	}
+}
+
+func TestValidateDefaults(t *testing.T) {
+	global.GetGlobalConfig().Port = 8080
+	defer func() { global.GetGlobalConfig().Port = 0 }()
+	negative := int64(-1)
+	tests := []struct {
+		name  string
+		spec  SpringBootDefaultsSpec
+		valid bool
+	}{
+		{"empty", SpringBootDefaultsSpec{}, true},
+		{"valid", SpringBootDefaultsSpec{RequestCpu: "100m", HostLogPath: "/logs", PodAntiAffinity: "Hard"}, true},
+		{"invalid quantity", SpringBootDefaultsSpec{LimitMemory: "2 gigs"}, false},
+		{"relative path", SpringBootDefaultsSpec{LivenessPath: "health"}, false},
+		{"port out of range", SpringBootDefaultsSpec{Port: 70000}, false},
+		{"node affinity without operator", SpringBootDefaultsSpec{NodeAffinityKey: "zone"}, false},
+		{"negative fs group", SpringBootDefaultsSpec{FSGroup: &negative}, false},
+		{"env without name", SpringBootDefaultsSpec{Env: env("", "a")}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defaults := &SpringBootDefaults{ObjectMeta: metav1.ObjectMeta{Name: "team"}, Spec: tt.spec}
+			if err := defaults.ValidateCreate(); (err == nil) != tt.valid {
+				t.Errorf("ValidateCreate() = %v, want valid %v", err, tt.valid)
+			}
+		})
+	}
+}
+
+func TestDefaultsReachExistingApplications(t *testing.T) {
+	global.GetGlobalConfig().Port = 8080
+	defer func() { global.GetGlobalConfig().Port = 0 }()
+	// the spec is stored as written, every reconcile resolves it against the current defaults
+	stored := SpringBoot{Version: "v1"}
+	resolve := func(namespaceDefaults ...*SpringBootDefaultsSpec) int32 {
+		springBoot, err := stored.DeepCopy().Check("demo", namespaceDefaults...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return springBoot.Port
+	}
+
+	if port := resolve(); port != 8080 {
+		t.Errorf("port = %d, want the operator default 8080", port)
+	}
+	if port := resolve(&SpringBootDefaultsSpec{Port: 9090}); port != 9090 {
+		t.Errorf("port = %d, want the namespace default 9090 added later", port)
+	}
+	global.GetGlobalConfig().Port = 8081
+	if port := resolve(); port != 8081 {
+		t.Errorf("port = %d, want the reloaded operator default 8081", port)
+	}
+}
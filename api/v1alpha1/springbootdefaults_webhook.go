@@ -0,0 +1,70 @@
+/*
+Copyright 2020 qingmu.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"encoding/json"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+
+	"spring-boot-operator/global"
+)
+
+func (r *SpringBootDefaults) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+// +kubebuilder:webhook:verbs=create;update,path=/validate-springboot-qingmu-io-v1alpha1-springbootdefaults,mutating=false,failurePolicy=fail,groups=springboot.qingmu.io,resources=springbootdefaults,versions=v1alpha1,name=vspringbootdefaults.kb.io
+
+var _ webhook.Validator = &SpringBootDefaults{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
+func (r *SpringBootDefaults) ValidateCreate() error {
+	return r.validate()
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
+func (r *SpringBootDefaults) ValidateUpdate(old runtime.Object) error {
+	return r.validate()
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (r *SpringBootDefaults) ValidateDelete() error {
+	return nil
+}
+
+// validate checks the defaults with the rules of the operator configuration. They are merged onto
+// the operator defaults first, as the applications see them, so the unset values are not rejected
+func (r *SpringBootDefaults) validate() error {
+	defaults := operatorDefaults()
+	defaults.merge(&r.Spec)
+	data, err := json.Marshal(defaults)
+	if err != nil {
+		return err
+	}
+	if err := global.Validate(data); err != nil {
+		return apierrors.NewInvalid(GroupVersion.WithKind("SpringBootDefaults").GroupKind(), r.Name,
+			field.ErrorList{field.Forbidden(field.NewPath("spec"), err.Error())})
+	}
+	return nil
+}
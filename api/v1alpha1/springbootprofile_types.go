@@ -0,0 +1,79 @@
+/*
+Copyright 2020 qingmu.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"encoding/json"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+)
+
+// SpringBootProfileSpec defines a partial spring boot body shared by the applications referencing the profile
+type SpringBootProfileSpec struct {
+	// The spring boot body, e.g. the resource, probes, jvm and affinity of a kind of application
+	SpringBoot SpringBoot `json:"springBoot,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster,shortName=sbp
+
+// SpringBootProfile is the Schema for the springbootprofiles API
+type SpringBootProfile struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec SpringBootProfileSpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// SpringBootProfileList contains a list of SpringBootProfile
+type SpringBootProfileList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []SpringBootProfile `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&SpringBootProfile{}, &SpringBootProfileList{})
+}
+
+// Merge returns the spring boot body of the profile overridden by s, using the strategic merge
+// of kubernetes: lists of named items are merged by name, other values set in s replace the profile ones.
+// The empty values of s are omitted, so s can not reset a profile value to false, 0 or "", e.g.
+// autoscaling.enabled. Only the pointer fields, e.g. probes.healthGroups, override it with false or 0.
+// Neither the profile nor s are modified
+func (p *SpringBootProfile) Merge(s *SpringBoot) (*SpringBoot, error) {
+	profile, err := json.Marshal(&p.Spec.SpringBoot)
+	if err != nil {
+		return nil, err
+	}
+	overrides, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	merged, err := strategicpatch.StrategicMergePatch(profile, overrides, SpringBoot{})
+	if err != nil {
+		return nil, err
+	}
+	springBoot := &SpringBoot{}
+	if err := json.Unmarshal(merged, springBoot); err != nil {
+		return nil, err
+	}
+	return springBoot, nil
+}
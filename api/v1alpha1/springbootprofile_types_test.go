@@ -0,0 +1,106 @@
+/*
+Copyright 2020 qingmu.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"reflect"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestProfileMerge(t *testing.T) {
+	enabled, disabled := true, false
+	emptyDir := v1.VolumeSource{EmptyDir: &v1.EmptyDirVolumeSource{}}
+	profile := SpringBoot{
+		Port:        8080,
+		Replicas:    2,
+		Env:         env("LEVEL", "profile", "TEAM", "profile"),
+		Sidecars:    []v1.Container{{Name: "log-shipper", Image: "shipper:1"}, {Name: "proxy", Image: "proxy:1"}},
+		Volumes:     []v1.Volume{{Name: "cache", VolumeSource: emptyDir}},
+		Autoscaling: AutoscalingSpec{Enabled: true, MaxReplicas: 5},
+		Probes:      ProbesSpec{HealthGroups: &enabled},
+	}
+	tests := []struct {
+		name       string
+		springBoot SpringBoot
+		check      func(merged *SpringBoot) bool
+	}{
+		{
+			name:       "profile",
+			springBoot: SpringBoot{},
+			check:      func(m *SpringBoot) bool { return reflect.DeepEqual(m, &profile) },
+		},
+		{
+			name:       "scalar override",
+			springBoot: SpringBoot{Port: 9090, Version: "v1"},
+			check:      func(m *SpringBoot) bool { return m.Port == 9090 && m.Version == "v1" && m.Replicas == 2 },
+		},
+		{
+			name:       "env merged by name",
+			springBoot: SpringBoot{Env: env("LEVEL", "app", "APP", "app")},
+			check: func(m *SpringBoot) bool {
+				values := map[string]string{}
+				for _, env := range m.Env {
+					values[env.Name] = env.Value
+				}
+				return len(m.Env) == 3 && reflect.DeepEqual(values, map[string]string{"LEVEL": "app", "TEAM": "profile", "APP": "app"})
+			},
+		},
+		{
+			name:       "sidecars merged by name",
+			springBoot: SpringBoot{Sidecars: []v1.Container{{Name: "proxy", Image: "proxy:2"}}},
+			check: func(m *SpringBoot) bool {
+				return len(m.Sidecars) == 2 && m.Sidecars[0].Image == "shipper:1" && m.Sidecars[1].Image == "proxy:2"
+			},
+		},
+		{
+			name:       "volumes merged by name",
+			springBoot: SpringBoot{Volumes: []v1.Volume{{Name: "data", VolumeSource: emptyDir}}},
+			check:      func(m *SpringBoot) bool { return len(m.Volumes) == 2 },
+		},
+		{
+			name:       "optional field overridden with false",
+			springBoot: SpringBoot{Probes: ProbesSpec{HealthGroups: &disabled}},
+			check:      func(m *SpringBoot) bool { return m.Probes.HealthGroups != nil && !*m.Probes.HealthGroups },
+		},
+		{
+			name:       "false keeps the profile value",
+			springBoot: SpringBoot{Autoscaling: AutoscalingSpec{Enabled: false}},
+			check:      func(m *SpringBoot) bool { return m.Autoscaling.Enabled && m.Autoscaling.MaxReplicas == 5 },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &SpringBootProfile{Spec: SpringBootProfileSpec{SpringBoot: *profile.DeepCopy()}}
+			springBoot := tt.springBoot.DeepCopy()
+			merged, err := p.Merge(springBoot)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !tt.check(merged) {
+				t.Errorf("Merge() = %+v", merged)
+			}
+			if !reflect.DeepEqual(&p.Spec.SpringBoot, &profile) {
+				t.Errorf("profile modified: %+v", p.Spec.SpringBoot)
+			}
+			if !reflect.DeepEqual(springBoot, &tt.springBoot) {
+				t.Errorf("spring boot body modified: %+v", springBoot)
+			}
+		})
+	}
+}
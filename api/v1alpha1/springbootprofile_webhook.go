@@ -0,0 +1,88 @@
+/*
+Copyright 2020 qingmu.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+func (r *SpringBootProfile) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	webhookReader = mgr.GetClient()
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+// +kubebuilder:webhook:verbs=create;update,path=/validate-springboot-qingmu-io-v1alpha1-springbootprofile,mutating=false,failurePolicy=fail,groups=springboot.qingmu.io,resources=springbootprofiles,versions=v1alpha1,name=vspringbootprofile.kb.io
+
+var _ webhook.Validator = &SpringBootProfile{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
+func (r *SpringBootProfile) ValidateCreate() error {
+	return r.validate()
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
+func (r *SpringBootProfile) ValidateUpdate(old runtime.Object) error {
+	return r.validate()
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (r *SpringBootProfile) ValidateDelete() error {
+	return nil
+}
+
+// validate checks the partial body of the profile, leaving out the missing values and references an
+// application can complete, then the body of every application referencing the profile merged onto it
+func (r *SpringBootProfile) validate() error {
+	fldPath := field.NewPath("spec", "springBoot")
+	var errs field.ErrorList
+	for _, err := range r.Spec.SpringBoot.Validate(fldPath) {
+		if err.Type != field.ErrorTypeRequired && err.Type != field.ErrorTypeNotFound {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) == 0 && webhookReader != nil {
+		apps := &SpringBootApplicationList{}
+		if err := webhookReader.List(context.Background(), apps); err != nil {
+			return err
+		}
+		for _, app := range apps.Items {
+			if app.Spec.ProfileRef != r.Name {
+				continue
+			}
+			springBoot, err := r.Merge(&app.Spec.SpringBoot)
+			if err != nil {
+				return err
+			}
+			if appErrs := springBoot.validateApplication(fldPath, app.Name); len(appErrs) > 0 {
+				errs = append(errs, field.Forbidden(fldPath, "the application "+app.Namespace+"/"+app.Name+
+					" referencing the profile becomes invalid: "+appErrs.ToAggregate().Error()))
+			}
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(GroupVersion.WithKind("SpringBootProfile").GroupKind(), r.Name, errs)
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2020 qingmu.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var cacheVolume = v1.Volume{Name: "cache", VolumeSource: v1.VolumeSource{EmptyDir: &v1.EmptyDirVolumeSource{}}}
+
+func useWebhookReader(t *testing.T, objs ...runtime.Object) {
+	scheme := runtime.NewScheme()
+	if err := AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	webhookReader = fake.NewFakeClientWithScheme(scheme, objs...)
+	t.Cleanup(func() { webhookReader = nil })
+}
+
+func TestValidateApplicationWithProfile(t *testing.T) {
+	profile := &SpringBootProfile{
+		ObjectMeta: metav1.ObjectMeta{Name: "cached"},
+		Spec: SpringBootProfileSpec{SpringBoot: SpringBoot{
+			Volumes:     []v1.Volume{cacheVolume},
+			Autoscaling: AutoscalingSpec{MaxReplicas: 5},
+		}},
+	}
+	tests := []struct {
+		name       string
+		profileRef string
+		springBoot SpringBoot
+		valid      bool
+	}{
+		{"profile volume", "cached", SpringBoot{VolumeMounts: []v1.VolumeMount{{Name: "cache", MountPath: "/cache"}}}, true},
+		{"profile maxReplicas", "cached", SpringBoot{Autoscaling: AutoscalingSpec{Enabled: true}}, true},
+		{"unknown volume", "cached", SpringBoot{VolumeMounts: []v1.VolumeMount{{Name: "missing", MountPath: "/cache"}}}, false},
+		{"missing profile", "missing", SpringBoot{VolumeMounts: []v1.VolumeMount{{Name: "cache", MountPath: "/cache"}}}, true},
+		{"no profile", "", SpringBoot{VolumeMounts: []v1.VolumeMount{{Name: "cache", MountPath: "/cache"}}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useWebhookReader(t, profile)
+			tt.springBoot.Version = "v1.0.0"
+			app := &SpringBootApplication{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "demo"},
+				Spec:       SpringBootApplicationSpec{ProfileRef: tt.profileRef, SpringBoot: tt.springBoot},
+			}
+			err := app.ValidateCreate()
+			if valid := err == nil; valid != tt.valid {
+				t.Errorf("ValidateCreate() = %v, want valid %v", err, tt.valid)
+			}
+		})
+	}
+}
+
+func TestValidateProfile(t *testing.T) {
+	app := &SpringBootApplication{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "demo"},
+		Spec: SpringBootApplicationSpec{ProfileRef: "cached", SpringBoot: SpringBoot{
+			Version:      "v1.0.0",
+			VolumeMounts: []v1.VolumeMount{{Name: "cache", MountPath: "/cache"}},
+		}},
+	}
+	tests := []struct {
+		name       string
+		profile    string
+		springBoot SpringBoot
+		valid      bool
+	}{
+		{"mount of a volume of the applications", "shared", SpringBoot{VolumeMounts: []v1.VolumeMount{{Name: "data", MountPath: "/data"}}}, true},
+		{"maxReplicas of the applications", "shared", SpringBoot{Autoscaling: AutoscalingSpec{Enabled: true}}, true},
+		{"unsupported value", "shared", SpringBoot{PodAntiAffinity: "sometimes"}, false},
+		{"volume of a referencing application", "cached", SpringBoot{Volumes: []v1.Volume{cacheVolume}}, true},
+		{"volume removed from a referencing application", "cached", SpringBoot{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useWebhookReader(t, app)
+			profile := &SpringBootProfile{
+				ObjectMeta: metav1.ObjectMeta{Name: tt.profile},
+				Spec:       SpringBootProfileSpec{SpringBoot: tt.springBoot},
+			}
+			err := profile.ValidateUpdate(profile.DeepCopy())
+			if valid := err == nil; valid != tt.valid {
+				t.Errorf("ValidateUpdate() = %v, want valid %v", err, tt.valid)
+			}
+		})
+	}
+}
@@ -0,0 +1,232 @@
+/*
+Copyright 2020 qingmu.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2beta2 "k8s.io/api/autoscaling/v2beta2"
+	v1 "k8s.io/api/core/v1"
+	networkingv1beta1 "k8s.io/api/networking/v1beta1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	springbootv1alpha1 "spring-boot-operator/api/v1alpha1"
+)
+
+// fieldManager owns the fields of the generated objects set by the operator
+const fieldManager = "spring-boot-operator"
+
+// fieldPath is the path of a field in a generated object, e.g. spec.replicas
+type fieldPath []string
+
+// replicasField is handed over to the horizontal pod autoscaler
+var replicasField = fieldPath{"spec", "replicas"}
+
+// applyOwned applies obj, controlled by the application, with server-side apply. The operator only
+// owns the fields set in obj: a field it stops setting is removed, fields set by other managers,
+// e.g. injected sidecars, are kept. The released fields are given up without being removed, so obj
+// can leave them unset for another manager. obj is updated with the applied object
+func (r *SpringBootApplicationReconciler) applyOwned(ctx context.Context, app *springbootv1alpha1.SpringBootApplication,
+	obj ownedObject, released ...fieldPath) (controllerutil.OperationResult, error) {
+	current := obj.DeepCopyObject().(ownedObject)
+	key := client.ObjectKey{Namespace: obj.GetNamespace(), Name: obj.GetName()}
+	exists := true
+	if err := r.Get(ctx, key, current); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return controllerutil.OperationResultNone, err
+		}
+		exists = false
+	}
+	if exists {
+		// current is the object after the edit, the result of the apply is compared to it
+		if err := r.updateManagedFields(ctx, current, released); err != nil {
+			return controllerutil.OperationResultNone, err
+		}
+	}
+
+	if err := controllerutil.SetControllerReference(app, obj, r.Scheme); err != nil {
+		return controllerutil.OperationResultNone, err
+	}
+	gvk, err := apiutil.GVKForObject(obj, r.Scheme)
+	if err != nil {
+		return controllerutil.OperationResultNone, err
+	}
+	obj.GetObjectKind().SetGroupVersionKind(gvk)
+	obj.SetManagedFields(nil)
+	obj.SetResourceVersion("")
+	// the response is decoded into a copy, decoding merges into the maps obj may share with other objects
+	applied := obj.DeepCopyObject().(ownedObject)
+	if err := r.Patch(ctx, applied, client.Apply, client.FieldOwner(fieldManager), client.ForceOwnership); err != nil {
+		return controllerutil.OperationResultNone, err
+	}
+	if err := copyApplied(obj, applied); err != nil {
+		return controllerutil.OperationResultNone, err
+	}
+	switch {
+	case !exists:
+		return controllerutil.OperationResultCreated, nil
+	case obj.GetResourceVersion() != current.GetResourceVersion():
+		return controllerutil.OperationResultUpdated, nil
+	default:
+		return controllerutil.OperationResultNone, nil
+	}
+}
+
+// updateManagedFields hands the fields the operator set with updates, as LegacyFieldManager, over to the
+// apply field manager, otherwise they would never be removed once the operator stops setting them. The
+// released fields are removed from the fields of the apply field manager. The edit is rejected with a
+// conflict when obj is stale
+func (r *SpringBootApplicationReconciler) updateManagedFields(ctx context.Context, obj ownedObject, released []fieldPath) error {
+	gvk, err := apiutil.GVKForObject(obj, r.Scheme)
+	if err != nil {
+		return err
+	}
+	managedFields := obj.GetManagedFields()
+	applyIndex := -1
+	for i, entry := range managedFields {
+		if entry.Manager == fieldManager && entry.Operation == metav1.ManagedFieldsOperationApply {
+			applyIndex = i
+			break
+		}
+	}
+	changed := false
+	if applyIndex < 0 && r.LegacyFieldManager != "" {
+		for i, entry := range managedFields {
+			if entry.Manager == r.LegacyFieldManager && entry.Operation == metav1.ManagedFieldsOperationUpdate &&
+				entry.APIVersion == gvk.GroupVersion().String() {
+				managedFields[i].Manager = fieldManager
+				managedFields[i].Operation = metav1.ManagedFieldsOperationApply
+				applyIndex = i
+				changed = true
+				break
+			}
+		}
+	}
+	if applyIndex >= 0 {
+		for _, path := range released {
+			owned, err := releaseField(managedFields[applyIndex].FieldsV1, path)
+			if err != nil {
+				return err
+			}
+			changed = changed || owned
+		}
+	}
+	if !changed {
+		return nil
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"resourceVersion": obj.GetResourceVersion(),
+			"managedFields":   managedFields,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	return r.Patch(ctx, obj, client.ConstantPatch(types.MergePatchType, patch))
+}
+
+// releaseField removes the field from the set of managed fields, it reports whether the field was in it
+func releaseField(fields *metav1.FieldsV1, path fieldPath) (bool, error) {
+	if fields == nil || len(path) == 0 {
+		return false, nil
+	}
+	set := map[string]interface{}{}
+	if err := json.Unmarshal(fields.Raw, &set); err != nil {
+		return false, err
+	}
+	if !removeField(set, path) {
+		return false, nil
+	}
+	raw, err := json.Marshal(set)
+	if err != nil {
+		return false, err
+	}
+	fields.Raw = raw
+	return true, nil
+}
+
+// removeField removes the path from the fields set, and its parents left without fields
+func removeField(set map[string]interface{}, path fieldPath) bool {
+	key := "f:" + path[0]
+	if len(path) == 1 {
+		if _, ok := set[key]; !ok {
+			return false
+		}
+		delete(set, key)
+		return true
+	}
+	child, ok := set[key].(map[string]interface{})
+	if !ok || !removeField(child, path[1:]) {
+		return false
+	}
+	if len(child) == 0 {
+		delete(set, key)
+	}
+	return true
+}
+
+// copyApplied copies the applied object into obj, both are of the same type
+func copyApplied(obj ownedObject, applied ownedObject) error {
+	switch obj := obj.(type) {
+	case *appsv1.Deployment:
+		applied.(*appsv1.Deployment).DeepCopyInto(obj)
+	case *v1.Service:
+		applied.(*v1.Service).DeepCopyInto(obj)
+	case *v1.ConfigMap:
+		applied.(*v1.ConfigMap).DeepCopyInto(obj)
+	case *v1.ServiceAccount:
+		applied.(*v1.ServiceAccount).DeepCopyInto(obj)
+	case *autoscalingv2beta2.HorizontalPodAutoscaler:
+		applied.(*autoscalingv2beta2.HorizontalPodAutoscaler).DeepCopyInto(obj)
+	case *policyv1beta1.PodDisruptionBudget:
+		applied.(*policyv1beta1.PodDisruptionBudget).DeepCopyInto(obj)
+	case *networkingv1beta1.Ingress:
+		applied.(*networkingv1beta1.Ingress).DeepCopyInto(obj)
+	default:
+		return fmt.Errorf("unsupported generated object %T", obj)
+	}
+	return nil
+}
+
+// defaultPortProtocols sets the protocol of the container ports without one. The protocol is a key of
+// the ports list, before kubernetes 1.20 an apply omitting it is rejected instead of defaulted
+func defaultPortProtocols(podSpec *v1.PodSpec) {
+	for _, containers := range [][]v1.Container{podSpec.InitContainers, podSpec.Containers} {
+		for i := range containers {
+			ports := make([]v1.ContainerPort, len(containers[i].Ports))
+			for j, port := range containers[i].Ports {
+				if port.Protocol == "" {
+					port.Protocol = v1.ProtocolTCP
+				}
+				ports[j] = port
+			}
+			if len(ports) > 0 {
+				containers[i].Ports = ports
+			}
+		}
+	}
+}
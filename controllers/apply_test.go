@@ -0,0 +1,187 @@
+/*
+Copyright 2020 qingmu.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	springbootv1alpha1 "spring-boot-operator/api/v1alpha1"
+	"spring-boot-operator/global"
+)
+
+var _ = Describe("Applying the generated objects", func() {
+	var ctx context.Context
+	var reconciler *SpringBootApplicationReconciler
+
+	BeforeEach(func() {
+		requireEnvtest()
+		ctx = context.Background()
+		config := global.GetGlobalConfig()
+		config.ImageRepository = "registry.example.com"
+		config.RequestCpu = "50m"
+		config.RequestMemory = "512Mi"
+		config.LimitMemory = "512Mi"
+		config.LivenessPath = "/actuator/health"
+		config.ReadinessPath = "/actuator/health"
+		config.ShutdownPath = "/spring/shutdown"
+		config.HostLogPath = "/var/applog"
+		config.Replicas = 1
+		config.Port = 8080
+		reconciler = &SpringBootApplicationReconciler{
+			Client:   k8sClient,
+			Log:      ctrl.Log.WithName("controllers").WithName("SpringBootApplication"),
+			Scheme:   scheme.Scheme,
+			Recorder: record.NewFakeRecorder(100),
+		}
+	})
+
+	createApp := func(name string, springBoot springbootv1alpha1.SpringBoot) (types.NamespacedName, *springbootv1alpha1.SpringBootApplication) {
+		key := types.NamespacedName{Namespace: "default", Name: name}
+		springBoot.Version = "v1.0.0"
+		app := &springbootv1alpha1.SpringBootApplication{
+			ObjectMeta: metav1.ObjectMeta{Namespace: key.Namespace, Name: key.Name},
+			Spec:       springbootv1alpha1.SpringBootApplicationSpec{SpringBoot: springBoot},
+		}
+		Expect(k8sClient.Create(ctx, app)).To(Succeed())
+		return key, app
+	}
+
+	reconcile := func(key types.NamespacedName) *appsv1.Deployment {
+		_, err := reconciler.Reconcile(ctrl.Request{NamespacedName: key})
+		Expect(err).NotTo(HaveOccurred())
+		deploy := &appsv1.Deployment{}
+		Expect(k8sClient.Get(ctx, key, deploy)).To(Succeed())
+		return deploy
+	}
+
+	updateApp := func(key types.NamespacedName, update func(app *springbootv1alpha1.SpringBootApplication)) {
+		app := &springbootv1alpha1.SpringBootApplication{}
+		Expect(k8sClient.Get(ctx, key, app)).To(Succeed())
+		update(app)
+		Expect(k8sClient.Update(ctx, app)).To(Succeed())
+	}
+
+	envNames := func(deploy *appsv1.Deployment) []string {
+		var names []string
+		for _, env := range deploy.Spec.Template.Spec.Containers[0].Env {
+			names = append(names, env.Name)
+		}
+		return names
+	}
+
+	managedFields := func(deploy *appsv1.Deployment, manager string) map[string]interface{} {
+		for _, entry := range deploy.ManagedFields {
+			if entry.Manager == manager {
+				fields := map[string]interface{}{}
+				Expect(json.Unmarshal(entry.FieldsV1.Raw, &fields)).To(Succeed())
+				return fields
+			}
+		}
+		return nil
+	}
+
+	It("keeps the fields of other managers", func() {
+		key, _ := createApp("apply-other-manager", springbootv1alpha1.SpringBoot{})
+		deploy := reconcile(key)
+
+		patch := []byte(`{"metadata":{"annotations":{"example.com/injected":"true"}}}`)
+		Expect(k8sClient.Patch(ctx, deploy, client.ConstantPatch(types.MergePatchType, patch), client.FieldOwner("other"))).To(Succeed())
+		updateApp(key, func(app *springbootv1alpha1.SpringBootApplication) {
+			app.Spec.SpringBoot.Replicas = 2
+		})
+
+		deploy = reconcile(key)
+		Expect(*deploy.Spec.Replicas).To(Equal(int32(2)))
+		Expect(deploy.Annotations).To(HaveKeyWithValue("example.com/injected", "true"))
+	})
+
+	It("removes the fields the operator stops setting", func() {
+		key, _ := createApp("apply-removed-env", springbootv1alpha1.SpringBoot{
+			Env: []v1.EnvVar{{Name: "KEPT", Value: "1"}, {Name: "REMOVED", Value: "2"}},
+		})
+		Expect(envNames(reconcile(key))).To(ContainElement("REMOVED"))
+
+		updateApp(key, func(app *springbootv1alpha1.SpringBootApplication) {
+			app.Spec.SpringBoot.Env = []v1.EnvVar{{Name: "KEPT", Value: "1"}}
+		})
+		names := envNames(reconcile(key))
+		Expect(names).To(ContainElement("KEPT"))
+		Expect(names).NotTo(ContainElement("REMOVED"))
+	})
+
+	It("hands the fields of the legacy field manager over", func() {
+		key, _ := createApp("apply-legacy-manager", springbootv1alpha1.SpringBoot{})
+		deploy := reconcile(key)
+
+		By("rewriting the deployment as updated by the legacy field manager")
+		var entries []metav1.ManagedFieldsEntry
+		for _, entry := range deploy.ManagedFields {
+			if entry.Manager == fieldManager {
+				entry.Manager = "legacy-test"
+				entry.Operation = metav1.ManagedFieldsOperationUpdate
+			}
+			entries = append(entries, entry)
+		}
+		patch, err := json.Marshal(map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"annotations":   map[string]string{"example.com/legacy": "true"},
+				"managedFields": entries,
+			},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(k8sClient.Patch(ctx, deploy, client.ConstantPatch(types.MergePatchType, patch), client.FieldOwner("legacy-test"))).To(Succeed())
+		Expect(managedFields(deploy, fieldManager)).To(BeNil())
+		Expect(deploy.Annotations).To(HaveKey("example.com/legacy"))
+
+		reconciler.LegacyFieldManager = "legacy-test"
+		deploy = reconcile(key)
+		Expect(managedFields(deploy, "legacy-test")).To(BeNil())
+		Expect(managedFields(deploy, fieldManager)).NotTo(BeNil())
+		Expect(deploy.Annotations).NotTo(HaveKey("example.com/legacy"))
+	})
+
+	It("hands the replicas over to the horizontal pod autoscaler", func() {
+		key, _ := createApp("apply-autoscaling", springbootv1alpha1.SpringBoot{Replicas: 2})
+		deploy := reconcile(key)
+		Expect(managedFields(deploy, fieldManager)["f:spec"]).To(HaveKey("f:replicas"))
+
+		updateApp(key, func(app *springbootv1alpha1.SpringBootApplication) {
+			app.Spec.SpringBoot.Autoscaling = springbootv1alpha1.AutoscalingSpec{Enabled: true, MaxReplicas: 5}
+		})
+		deploy = reconcile(key)
+		Expect(managedFields(deploy, fieldManager)["f:spec"]).NotTo(HaveKey("f:replicas"))
+		Expect(*deploy.Spec.Replicas).To(Equal(int32(2)))
+
+		By("keeping the replicas scaled by the autoscaler")
+		patch := []byte(`{"spec":{"replicas":4}}`)
+		Expect(k8sClient.Patch(ctx, deploy, client.ConstantPatch(types.MergePatchType, patch), client.FieldOwner("autoscaler-test"))).To(Succeed())
+		deploy = reconcile(key)
+		Expect(*deploy.Spec.Replicas).To(Equal(int32(4)))
+	})
+})
@@ -0,0 +1,85 @@
+/*
+Copyright 2020 qingmu.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	autoscalingv2beta2 "k8s.io/api/autoscaling/v2beta2"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	springbootv1alpha1 "spring-boot-operator/api/v1alpha1"
+)
+
+// reconcileAutoscaler creates or updates the HorizontalPodAutoscaler of the application,
+// or deletes it when autoscaling is disabled
+func (r *SpringBootApplicationReconciler) reconcileAutoscaler(ctx context.Context, log logr.Logger,
+	app *springbootv1alpha1.SpringBootApplication, springBoot *springbootv1alpha1.SpringBoot, meta metav1.ObjectMeta) error {
+	hpa := &autoscalingv2beta2.HorizontalPodAutoscaler{ObjectMeta: meta}
+	if !springBoot.Autoscaling.Enabled {
+		deleted, err := r.deleteOwned(ctx, app, hpa)
+		if deleted {
+			log.Info("delete " + meta.Name + " horizontal pod autoscaler")
+			r.Recorder.Eventf(app, v1.EventTypeNormal, "HorizontalPodAutoscalerDeleted", "HorizontalPodAutoscaler %s deleted", meta.Name)
+		}
+		return err
+	}
+
+	autoscaling := springBoot.Autoscaling
+	minReplicas := springBoot.MinReplicas()
+	metrics := []autoscalingv2beta2.MetricSpec{}
+	if autoscaling.TargetCPUUtilizationPercentage != nil {
+		metrics = append(metrics, utilizationMetric(v1.ResourceCPU, *autoscaling.TargetCPUUtilizationPercentage))
+	}
+	if autoscaling.TargetMemoryUtilizationPercentage != nil {
+		metrics = append(metrics, utilizationMetric(v1.ResourceMemory, *autoscaling.TargetMemoryUtilizationPercentage))
+	}
+	metrics = append(metrics, autoscaling.Metrics...)
+
+	hpa.Spec = autoscalingv2beta2.HorizontalPodAutoscalerSpec{
+		ScaleTargetRef: autoscalingv2beta2.CrossVersionObjectReference{
+			APIVersion: "apps/v1",
+			Kind:       "Deployment",
+			Name:       meta.Name,
+		},
+		MinReplicas: &minReplicas,
+		MaxReplicas: autoscaling.MaxReplicas,
+		Metrics:     metrics,
+	}
+	op, err := r.applyOwned(ctx, app, hpa)
+	if err != nil {
+		return err
+	}
+	log.Info(string(op) + " " + meta.Name + " horizontal pod autoscaler")
+	r.recordOperation(app, "HorizontalPodAutoscaler", op)
+	return nil
+}
+
+func utilizationMetric(name v1.ResourceName, percentage int32) autoscalingv2beta2.MetricSpec {
+	return autoscalingv2beta2.MetricSpec{
+		Type: autoscalingv2beta2.ResourceMetricSourceType,
+		Resource: &autoscalingv2beta2.ResourceMetricSource{
+			Name: name,
+			Target: autoscalingv2beta2.MetricTarget{
+				Type:               autoscalingv2beta2.UtilizationMetricType,
+				AverageUtilization: &percentage,
+			},
+		},
+	}
+}
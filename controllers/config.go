@@ -0,0 +1,158 @@
+/*
+Copyright 2020 qingmu.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+
+	"github.com/go-logr/logr"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	springbootv1alpha1 "spring-boot-operator/api/v1alpha1"
+)
+
+const (
+	configVolumeName     = "config"
+	configMountPath      = springbootv1alpha1.ConfigMountPath
+	configHashAnnotation = "springboot.qingmu.io/config-hash"
+	// configMapIndexField indexes the applications by the name of the ConfigMap they reference
+	configMapIndexField = ".spec.springBoot.config.configMapName"
+)
+
+// configMount is the configuration mounted into the pods of an application
+type configMount struct {
+	configMapName string
+	hash          string
+}
+
+// reconcileConfig creates or updates the ConfigMap holding the inline configuration files,
+// or reads the referenced ConfigMap. It returns nil when the application has no configuration
+func (r *SpringBootApplicationReconciler) reconcileConfig(ctx context.Context, log logr.Logger,
+	app *springbootv1alpha1.SpringBootApplication, springBoot *springbootv1alpha1.SpringBoot, meta metav1.ObjectMeta) (*configMount, error) {
+	meta.Name = meta.Name + "-config"
+	configMap := &v1.ConfigMap{ObjectMeta: meta}
+	config := springBoot.Config
+	if len(config.Files) == 0 {
+		deleted, err := r.deleteOwned(ctx, app, configMap)
+		if deleted {
+			log.Info("delete " + meta.Name + " config map")
+			r.Recorder.Eventf(app, v1.EventTypeNormal, "ConfigMapDeleted", "ConfigMap %s deleted", meta.Name)
+		}
+		if err != nil || config.ConfigMapName == "" {
+			return nil, err
+		}
+		referenced := &v1.ConfigMap{}
+		if err := r.Get(ctx, types.NamespacedName{Namespace: meta.Namespace, Name: config.ConfigMapName}, referenced); err != nil {
+			return nil, err
+		}
+		return &configMount{configMapName: config.ConfigMapName, hash: hashData(referenced.Data, referenced.BinaryData)}, nil
+	}
+
+	configMap.Data = config.Files
+	op, err := r.applyOwned(ctx, app, configMap)
+	if err != nil {
+		return nil, err
+	}
+	log.Info(string(op) + " " + meta.Name + " config map")
+	r.recordOperation(app, "ConfigMap", op)
+	return &configMount{configMapName: meta.Name, hash: hashData(config.Files, nil)}, nil
+}
+
+// apply mounts the configuration into the pod and tells spring boot to load it.
+// The content hash is stamped onto the pod template so a change rolls the pods
+func (c *configMount) apply(podSpec *v1.PodSpec, container *v1.Container, annotations map[string]string) {
+	podSpec.Volumes = append(podSpec.Volumes, v1.Volume{
+		Name: configVolumeName,
+		VolumeSource: v1.VolumeSource{
+			ConfigMap: &v1.ConfigMapVolumeSource{
+				LocalObjectReference: v1.LocalObjectReference{Name: c.configMapName},
+			},
+		},
+	})
+	container.VolumeMounts = append(container.VolumeMounts, v1.VolumeMount{
+		Name:      configVolumeName,
+		ReadOnly:  true,
+		MountPath: configMountPath,
+	})
+	container.Env = setDefaultEnv(container.Env, v1.EnvVar{
+		Name:  "SPRING_CONFIG_ADDITIONAL_LOCATION",
+		Value: "file:" + configMountPath + "/",
+	})
+	annotations[configHashAnnotation] = c.hash
+}
+
+// setDefaultEnv appends env unless the variable is already set, the given slice is never modified
+func setDefaultEnv(envs []v1.EnvVar, env v1.EnvVar) []v1.EnvVar {
+	for _, e := range envs {
+		if e.Name == env.Name {
+			return envs
+		}
+	}
+	return append(envs[:len(envs):len(envs)], env)
+}
+
+// hashData returns a stable hash of the ConfigMap or Secret content
+func hashData(data map[string]string, binaryData map[string][]byte) string {
+	keys := make([]string, 0, len(data)+len(binaryData))
+	for k := range data {
+		keys = append(keys, k)
+	}
+	for k := range binaryData {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	hash := sha256.New()
+	for _, k := range keys {
+		hash.Write([]byte(k))
+		hash.Write([]byte{0})
+		if v, ok := data[k]; ok {
+			hash.Write([]byte(v))
+		} else {
+			hash.Write(binaryData[k])
+		}
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// indexConfigMapName is the index function of configMapIndexField
+func indexConfigMapName(obj runtime.Object) []string {
+	app, ok := obj.(*springbootv1alpha1.SpringBootApplication)
+	if !ok || app.Spec.SpringBoot.Config.ConfigMapName == "" {
+		return nil
+	}
+	return []string{app.Spec.SpringBoot.Config.ConfigMapName}
+}
+
+// configMapApplications maps a ConfigMap to the applications using it. The index only holds the ConfigMap
+// of the application spec, the ConfigMap of the profiles is looked up here
+func (r *SpringBootApplicationReconciler) configMapApplications(a handler.MapObject) []reconcile.Request {
+	name := a.Meta.GetName()
+	requests := r.referencingApplications(configMapIndexField)(a)
+	requests = append(requests, r.profileApplications(a.Meta.GetNamespace(), func(springBoot *springbootv1alpha1.SpringBoot) bool {
+		return springBoot.Config.ConfigMapName == name
+	})...)
+	return uniqueRequests(requests)
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2020 qingmu.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+
+	springbootv1alpha1 "spring-boot-operator/api/v1alpha1"
+)
+
+var _ = Describe("Mapping a ConfigMap to the applications using it", func() {
+	const namespace = "configmap-mapping"
+	var ctx context.Context
+	var reconciler *SpringBootApplicationReconciler
+	var stop chan struct{}
+
+	BeforeEach(func() {
+		requireEnvtest()
+		ctx = context.Background()
+		mgr, err := ctrl.NewManager(cfg, ctrl.Options{Scheme: scheme.Scheme, MetricsBindAddress: "0"})
+		Expect(err).NotTo(HaveOccurred())
+		reconciler = &SpringBootApplicationReconciler{
+			Client:   mgr.GetClient(),
+			Log:      ctrl.Log.WithName("controllers").WithName("SpringBootApplication"),
+			Scheme:   mgr.GetScheme(),
+			Recorder: mgr.GetEventRecorderFor("spring-boot-operator"),
+		}
+		// the indexes, the applications are not reconciled
+		Expect(reconciler.SetupWithManager(mgr)).To(Succeed())
+		stop = make(chan struct{})
+		go func() {
+			defer GinkgoRecover()
+			Expect(mgr.Start(stop)).To(Succeed())
+		}()
+	})
+
+	AfterEach(func() {
+		if stop != nil {
+			close(stop)
+		}
+	})
+
+	mapped := func(configMap string) func() []string {
+		return func() []string {
+			var names []string
+			object := &v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: configMap}}
+			for _, request := range reconciler.configMapApplications(handler.MapObject{Meta: object, Object: object}) {
+				names = append(names, request.Name)
+			}
+			sort.Strings(names)
+			return names
+		}
+	}
+
+	It("follows the ConfigMap of the applications and of the profiles", func() {
+		Expect(k8sClient.Create(ctx, &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace}})).To(Succeed())
+		Expect(k8sClient.Create(ctx, &springbootv1alpha1.SpringBootProfile{
+			ObjectMeta: metav1.ObjectMeta{Name: "configmap-mapping"},
+			Spec: springbootv1alpha1.SpringBootProfileSpec{SpringBoot: springbootv1alpha1.SpringBoot{
+				Config: springbootv1alpha1.ConfigSpec{ConfigMapName: "profile-config"},
+			}},
+		})).To(Succeed())
+		Expect(k8sClient.Create(ctx, &springbootv1alpha1.SpringBootApplication{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "plain"},
+			Spec: springbootv1alpha1.SpringBootApplicationSpec{SpringBoot: springbootv1alpha1.SpringBoot{
+				Version: "v1", Config: springbootv1alpha1.ConfigSpec{ConfigMapName: "app-config"},
+			}},
+		})).To(Succeed())
+		Expect(k8sClient.Create(ctx, &springbootv1alpha1.SpringBootApplication{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "profiled"},
+			Spec: springbootv1alpha1.SpringBootApplicationSpec{
+				ProfileRef: "configmap-mapping",
+				SpringBoot: springbootv1alpha1.SpringBoot{Version: "v1"},
+			},
+		})).To(Succeed())
+
+		Eventually(mapped("app-config"), 10*time.Second).Should(Equal([]string{"plain"}))
+		Eventually(mapped("profile-config"), 10*time.Second).Should(Equal([]string{"profiled"}))
+		Expect(mapped("other-config")()).To(BeEmpty())
+	})
+})
@@ -0,0 +1,49 @@
+/*
+Copyright 2020 qingmu.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	springbootv1alpha1 "spring-boot-operator/api/v1alpha1"
+)
+
+// namespaceDefaults returns the SpringBootDefaults of the namespace in the order they are applied
+func (r *SpringBootApplicationReconciler) namespaceDefaults(ctx context.Context, namespace string) ([]*springbootv1alpha1.SpringBootDefaultsSpec, error) {
+	return springbootv1alpha1.ListNamespaceDefaults(ctx, r.Client, namespace)
+}
+
+// namespaceApplications maps a SpringBootDefaults to the applications of its namespace
+func (r *SpringBootApplicationReconciler) namespaceApplications(a handler.MapObject) []reconcile.Request {
+	apps := &springbootv1alpha1.SpringBootApplicationList{}
+	if err := r.List(context.Background(), apps, client.InNamespace(a.Meta.GetNamespace())); err != nil {
+		r.Log.Error(err, "List namespace applications failed", "namespace", a.Meta.GetNamespace())
+		return nil
+	}
+	requests := make([]reconcile.Request, 0, len(apps.Items))
+	for _, app := range apps.Items {
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{Namespace: app.Namespace, Name: app.Name},
+		})
+	}
+	return requests
+}
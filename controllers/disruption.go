@@ -0,0 +1,61 @@
+/*
+Copyright 2020 qingmu.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	v1 "k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	springbootv1alpha1 "spring-boot-operator/api/v1alpha1"
+)
+
+// reconcilePodDisruptionBudget creates or updates the PodDisruptionBudget of the application.
+// A single replica can not be protected by a budget without blocking node drains, so the
+// budget is deleted when the application scales down to one replica
+func (r *SpringBootApplicationReconciler) reconcilePodDisruptionBudget(ctx context.Context, log logr.Logger,
+	app *springbootv1alpha1.SpringBootApplication, springBoot *springbootv1alpha1.SpringBoot, meta metav1.ObjectMeta) error {
+	pdb := &policyv1beta1.PodDisruptionBudget{ObjectMeta: meta}
+	budget := springBoot.PodDisruptionBudget
+	if budget.Disabled || springBoot.MinReplicas() <= 1 {
+		deleted, err := r.deleteOwned(ctx, app, pdb)
+		if deleted {
+			log.Info("delete " + meta.Name + " pod disruption budget")
+			r.Recorder.Eventf(app, v1.EventTypeNormal, "PodDisruptionBudgetDeleted", "PodDisruptionBudget %s deleted", meta.Name)
+		}
+		return err
+	}
+
+	pdb.Spec.Selector = &metav1.LabelSelector{MatchLabels: meta.Labels}
+	pdb.Spec.MinAvailable = budget.MinAvailable
+	pdb.Spec.MaxUnavailable = budget.MaxUnavailable
+	if budget.MinAvailable == nil && budget.MaxUnavailable == nil {
+		maxUnavailable := intstr.FromInt(1)
+		pdb.Spec.MaxUnavailable = &maxUnavailable
+	}
+	op, err := r.applyOwned(ctx, app, pdb)
+	if err != nil {
+		return err
+	}
+	log.Info(string(op) + " " + meta.Name + " pod disruption budget")
+	r.recordOperation(app, "PodDisruptionBudget", op)
+	return nil
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2020 qingmu.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	springbootv1alpha1 "spring-boot-operator/api/v1alpha1"
+	"spring-boot-operator/global"
+)
+
+// GlobalConfigReconciler reloads the operator configuration when its ConfigMap changes
+// and requeues every application, so the new defaults are applied
+type GlobalConfigReconciler struct {
+	client.Client
+	Log      logr.Logger
+	Recorder record.EventRecorder
+	// The ConfigMap holding the operator configuration
+	ConfigMap types.NamespacedName
+	// Receives an event for every application when the configuration changed
+	ConfigChanges chan<- event.GenericEvent
+}
+
+func (r *GlobalConfigReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	ctx := context.Background()
+	log := r.Log.WithValues("configmap", req.NamespacedName)
+
+	configMap := &v1.ConfigMap{}
+	found := true
+	if err := r.Get(ctx, req.NamespacedName, configMap); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return ctrl.Result{}, err
+		}
+		found = false
+	}
+	var data []byte
+	if value, ok := configMap.Data[global.ConfigMapKey]; ok {
+		data = []byte(value)
+	}
+
+	// the applications are listed first, a failure must not leave a reloaded configuration unapplied
+	apps := &springbootv1alpha1.SpringBootApplicationList{}
+	if err := r.List(ctx, apps); err != nil {
+		return ctrl.Result{}, err
+	}
+	changed, err := global.Load(data)
+	if err != nil {
+		// an invalid configuration is not retried, the next change of the ConfigMap triggers a reconcile
+		log.Error(err, "Invalid operator configuration, keeping the current one")
+		if found {
+			r.Recorder.Event(configMap, v1.EventTypeWarning, "InvalidConfig", err.Error())
+		}
+		return ctrl.Result{}, nil
+	}
+	if !changed {
+		return ctrl.Result{}, nil
+	}
+
+	if data == nil {
+		log.Info("Operator configuration reset to the env configuration")
+	} else {
+		log.Info("Operator configuration reloaded")
+	}
+	if found {
+		r.Recorder.Eventf(configMap, v1.EventTypeNormal, "ConfigReloaded",
+			"Operator configuration reloaded, requeued %d applications", len(apps.Items))
+	}
+	for i := range apps.Items {
+		app := &apps.Items[i]
+		r.ConfigChanges <- event.GenericEvent{Meta: app, Object: app}
+	}
+	return ctrl.Result{}, nil
+}
+
+func (r *GlobalConfigReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	isConfigMap := func(meta metav1.Object) bool {
+		return meta.GetNamespace() == r.ConfigMap.Namespace && meta.GetName() == r.ConfigMap.Name
+	}
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("globalconfig").
+		For(&v1.ConfigMap{}).
+		WithEventFilter(predicate.Funcs{
+			CreateFunc:  func(e event.CreateEvent) bool { return isConfigMap(e.Meta) },
+			UpdateFunc:  func(e event.UpdateEvent) bool { return isConfigMap(e.MetaNew) },
+			DeleteFunc:  func(e event.DeleteEvent) bool { return isConfigMap(e.Meta) },
+			GenericFunc: func(e event.GenericEvent) bool { return isConfigMap(e.Meta) },
+		}).
+		Complete(r)
+}
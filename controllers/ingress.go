@@ -0,0 +1,96 @@
+/*
+Copyright 2020 qingmu.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	v1 "k8s.io/api/core/v1"
+	networkingv1beta1 "k8s.io/api/networking/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	springbootv1alpha1 "spring-boot-operator/api/v1alpha1"
+)
+
+const ingressClassAnnotation = "kubernetes.io/ingress.class"
+
+// reconcileIngress creates or updates the Ingress of the application, or deletes it when the ingress is disabled
+func (r *SpringBootApplicationReconciler) reconcileIngress(ctx context.Context, log logr.Logger,
+	app *springbootv1alpha1.SpringBootApplication, springBoot *springbootv1alpha1.SpringBoot, meta metav1.ObjectMeta) error {
+	ingress := &networkingv1beta1.Ingress{ObjectMeta: meta}
+	spec := springBoot.Ingress
+	if !spec.Enabled {
+		deleted, err := r.deleteOwned(ctx, app, ingress)
+		if deleted {
+			log.Info("delete " + meta.Name + " ingress")
+			r.Recorder.Eventf(app, v1.EventTypeNormal, "IngressDeleted", "Ingress %s deleted", meta.Name)
+		}
+		return err
+	}
+
+	annotations := map[string]string{}
+	for k, v := range spec.Annotations {
+		annotations[k] = v
+	}
+	if spec.IngressClass != "" {
+		annotations[ingressClassAnnotation] = spec.IngressClass
+	}
+	ingress.Annotations = annotations
+
+	paths := spec.Paths
+	if len(paths) == 0 {
+		paths = []string{"/"}
+	}
+	backend := networkingv1beta1.IngressBackend{
+		ServiceName: meta.Name,
+		ServicePort: intstr.FromInt(int(springBoot.Port)),
+	}
+	httpPaths := make([]networkingv1beta1.HTTPIngressPath, 0, len(paths))
+	for _, path := range paths {
+		httpPaths = append(httpPaths, networkingv1beta1.HTTPIngressPath{Path: path, Backend: backend})
+	}
+	ruleValue := networkingv1beta1.IngressRuleValue{
+		HTTP: &networkingv1beta1.HTTPIngressRuleValue{Paths: httpPaths},
+	}
+
+	// a rule without host matches all hosts
+	hosts := spec.Hosts
+	if len(hosts) == 0 {
+		hosts = []string{""}
+	}
+	rules := make([]networkingv1beta1.IngressRule, 0, len(hosts))
+	for _, host := range hosts {
+		rules = append(rules, networkingv1beta1.IngressRule{Host: host, IngressRuleValue: ruleValue})
+	}
+
+	ingress.Spec = networkingv1beta1.IngressSpec{Rules: rules}
+	if spec.TLSSecretName != "" {
+		ingress.Spec.TLS = []networkingv1beta1.IngressTLS{{
+			Hosts:      spec.Hosts,
+			SecretName: spec.TLSSecretName,
+		}}
+	}
+	op, err := r.applyOwned(ctx, app, ingress)
+	if err != nil {
+		return err
+	}
+	log.Info(string(op) + " " + meta.Name + " ingress")
+	r.recordOperation(app, "Ingress", op)
+	return nil
+}
@@ -0,0 +1,102 @@
+/*
+Copyright 2020 qingmu.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	springbootv1alpha1 "spring-boot-operator/api/v1alpha1"
+)
+
+const (
+	javaToolOptionsEnv = "JAVA_TOOL_OPTIONS"
+	kibibyte           = 1024
+	mebibyte           = 1024 * kibibyte
+)
+
+// applyJvm sets JAVA_TOOL_OPTIONS on the container. A plain value set by the user is
+// appended to the computed options, the jvm keeps the last occurrence of an option
+func applyJvm(container *v1.Container, jvm *springbootv1alpha1.JvmSpec, resources v1.ResourceRequirements) {
+	if jvm == nil {
+		return
+	}
+	options := javaToolOptions(jvm, resources.Limits.Memory())
+	envs := make([]v1.EnvVar, 0, len(container.Env)+1)
+	found := false
+	for _, env := range container.Env {
+		if env.Name == javaToolOptionsEnv {
+			found = true
+			if env.ValueFrom == nil && env.Value != "" {
+				env.Value = options + " " + env.Value
+			}
+		}
+		envs = append(envs, env)
+	}
+	if !found {
+		envs = append(envs, v1.EnvVar{Name: javaToolOptionsEnv, Value: options})
+	}
+	container.Env = envs
+}
+
+// jvmSize formats a size in bytes for a jvm option, in mebibytes when it is a whole number of them.
+// Kibibytes are rounded down, the validation keeps the sizes above the jvm minimum
+func jvmSize(size int64) string {
+	if size%mebibyte == 0 {
+		return fmt.Sprintf("%dm", size/mebibyte)
+	}
+	return fmt.Sprintf("%dk", size/kibibyte)
+}
+
+// javaToolOptions computes the jvm options, the heap is sized from the memory limit
+func javaToolOptions(jvm *springbootv1alpha1.JvmSpec, memoryLimit *resource.Quantity) string {
+	heapPercentage := int64(jvm.HeapPercentage)
+	if heapPercentage == 0 {
+		heapPercentage = springbootv1alpha1.DefaultHeapPercentage
+	}
+	var options []string
+	if memoryLimit.IsZero() {
+		options = append(options, fmt.Sprintf("-XX:MaxRAMPercentage=%d.0", heapPercentage))
+	} else {
+		options = append(options, "-Xmx"+jvmSize(memoryLimit.Value()*heapPercentage/100))
+	}
+	if jvm.MaxMetaspaceSize != "" {
+		// invalid quantities are rejected by the validation
+		if metaspace, err := resource.ParseQuantity(jvm.MaxMetaspaceSize); err == nil {
+			options = append(options, "-XX:MaxMetaspaceSize="+jvmSize(metaspace.Value()))
+		}
+	}
+	switch jvm.GC {
+	case "":
+	case springbootv1alpha1.GCShenandoah, springbootv1alpha1.GCZ:
+		// experimental before jdk 15, the unlock is accepted by later jdks
+		options = append(options, "-XX:+UnlockExperimentalVMOptions", "-XX:+Use"+jvm.GC+"GC")
+	default:
+		options = append(options, "-XX:+Use"+jvm.GC+"GC")
+	}
+	if jvm.ExitOnOutOfMemoryError == nil || *jvm.ExitOnOutOfMemoryError {
+		options = append(options, "-XX:+ExitOnOutOfMemoryError")
+	}
+	if jvm.HeapDumpPath != "" {
+		options = append(options, "-XX:+HeapDumpOnOutOfMemoryError", "-XX:HeapDumpPath="+jvm.HeapDumpPath)
+	}
+	options = append(options, jvm.Options...)
+	return strings.Join(options, " ")
+}
@@ -0,0 +1,83 @@
+/*
+Copyright 2020 qingmu.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	springbootv1alpha1 "spring-boot-operator/api/v1alpha1"
+)
+
+func TestJavaToolOptions(t *testing.T) {
+	disabled := false
+	tests := []struct {
+		name        string
+		jvm         springbootv1alpha1.JvmSpec
+		memoryLimit string
+		want        string
+	}{
+		{"unlimited memory", springbootv1alpha1.JvmSpec{}, "0", "-XX:MaxRAMPercentage=75.0 -XX:+ExitOnOutOfMemoryError"},
+		{"heap in mebibytes", springbootv1alpha1.JvmSpec{}, "1Gi", "-Xmx768m -XX:+ExitOnOutOfMemoryError"},
+		{"heap in kibibytes", springbootv1alpha1.JvmSpec{HeapPercentage: 50}, "3Mi", "-Xmx1536k -XX:+ExitOnOutOfMemoryError"},
+		{"decimal memory limit", springbootv1alpha1.JvmSpec{HeapPercentage: 50}, "1G", "-Xmx488281k -XX:+ExitOnOutOfMemoryError"},
+		{"metaspace in mebibytes", springbootv1alpha1.JvmSpec{MaxMetaspaceSize: "256Mi", ExitOnOutOfMemoryError: &disabled},
+			"1Gi", "-Xmx768m -XX:MaxMetaspaceSize=256m"},
+		{"metaspace in kibibytes", springbootv1alpha1.JvmSpec{MaxMetaspaceSize: "1500Ki", ExitOnOutOfMemoryError: &disabled},
+			"1Gi", "-Xmx768m -XX:MaxMetaspaceSize=1500k"},
+		{"g1", springbootv1alpha1.JvmSpec{GC: springbootv1alpha1.GCG1, ExitOnOutOfMemoryError: &disabled},
+			"1Gi", "-Xmx768m -XX:+UseG1GC"},
+		{"shenandoah unlocked", springbootv1alpha1.JvmSpec{GC: springbootv1alpha1.GCShenandoah, ExitOnOutOfMemoryError: &disabled},
+			"1Gi", "-Xmx768m -XX:+UnlockExperimentalVMOptions -XX:+UseShenandoahGC"},
+		{"z unlocked", springbootv1alpha1.JvmSpec{GC: springbootv1alpha1.GCZ, ExitOnOutOfMemoryError: &disabled},
+			"1Gi", "-Xmx768m -XX:+UnlockExperimentalVMOptions -XX:+UseZGC"},
+		{"heap dump and options", springbootv1alpha1.JvmSpec{HeapDumpPath: "/var/applog", Options: []string{"-Dfile.encoding=UTF-8"}},
+			"1Gi", "-Xmx768m -XX:+ExitOnOutOfMemoryError -XX:+HeapDumpOnOutOfMemoryError -XX:HeapDumpPath=/var/applog -Dfile.encoding=UTF-8"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			memoryLimit := resource.MustParse(tt.memoryLimit)
+			if got := javaToolOptions(&tt.jvm, &memoryLimit); got != tt.want {
+				t.Errorf("javaToolOptions() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyJvm(t *testing.T) {
+	resources := v1.ResourceRequirements{Limits: v1.ResourceList{v1.ResourceMemory: resource.MustParse("1Gi")}}
+	tests := []struct {
+		name string
+		env  []v1.EnvVar
+		want []v1.EnvVar
+	}{
+		{"added", nil, []v1.EnvVar{{Name: javaToolOptionsEnv, Value: "-Xmx768m -XX:+ExitOnOutOfMemoryError"}}},
+		{"user options appended", []v1.EnvVar{{Name: javaToolOptionsEnv, Value: "-Xmx512m"}},
+			[]v1.EnvVar{{Name: javaToolOptionsEnv, Value: "-Xmx768m -XX:+ExitOnOutOfMemoryError -Xmx512m"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			container := &v1.Container{Env: tt.env}
+			applyJvm(container, &springbootv1alpha1.JvmSpec{}, resources)
+			if len(container.Env) != len(tt.want) || container.Env[0] != tt.want[0] {
+				t.Errorf("env = %v, want %v", container.Env, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2020 qingmu.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2beta2 "k8s.io/api/autoscaling/v2beta2"
+	v1 "k8s.io/api/core/v1"
+	networkingv1beta1 "k8s.io/api/networking/v1beta1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	springbootv1alpha1 "spring-boot-operator/api/v1alpha1"
+)
+
+// ignoreStatusChanges drops update events that only touch the status of an object,
+// except the deployment rollout progress which is mirrored into the application status
+var ignoreStatusChanges = predicate.Funcs{
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		if e.MetaOld == nil || e.MetaNew == nil {
+			return true
+		}
+		if metadataChanged(e.MetaOld, e.MetaNew) {
+			return true
+		}
+		switch newObj := e.ObjectNew.(type) {
+		case *springbootv1alpha1.SpringBootApplication:
+			return e.MetaOld.GetGeneration() != e.MetaNew.GetGeneration()
+		case *springbootv1alpha1.SpringBootDefaults:
+			oldObj, ok := e.ObjectOld.(*springbootv1alpha1.SpringBootDefaults)
+			return !ok || !equality.Semantic.DeepEqual(oldObj.Spec, newObj.Spec)
+		case *springbootv1alpha1.SpringBootProfile:
+			oldObj, ok := e.ObjectOld.(*springbootv1alpha1.SpringBootProfile)
+			return !ok || !equality.Semantic.DeepEqual(oldObj.Spec, newObj.Spec)
+		case *appsv1.Deployment:
+			oldObj, ok := e.ObjectOld.(*appsv1.Deployment)
+			return !ok || e.MetaOld.GetGeneration() != e.MetaNew.GetGeneration() || rolloutChanged(oldObj, newObj)
+		case *v1.Service:
+			oldObj, ok := e.ObjectOld.(*v1.Service)
+			return !ok || !equality.Semantic.DeepEqual(oldObj.Spec, newObj.Spec)
+		case *autoscalingv2beta2.HorizontalPodAutoscaler:
+			oldObj, ok := e.ObjectOld.(*autoscalingv2beta2.HorizontalPodAutoscaler)
+			return !ok || !equality.Semantic.DeepEqual(oldObj.Spec, newObj.Spec)
+		case *policyv1beta1.PodDisruptionBudget:
+			oldObj, ok := e.ObjectOld.(*policyv1beta1.PodDisruptionBudget)
+			return !ok || !equality.Semantic.DeepEqual(oldObj.Spec, newObj.Spec)
+		case *networkingv1beta1.Ingress:
+			oldObj, ok := e.ObjectOld.(*networkingv1beta1.Ingress)
+			return !ok || !equality.Semantic.DeepEqual(oldObj.Spec, newObj.Spec)
+		case *v1.ConfigMap:
+			oldObj, ok := e.ObjectOld.(*v1.ConfigMap)
+			return !ok || !equality.Semantic.DeepEqual(oldObj.Data, newObj.Data) ||
+				!equality.Semantic.DeepEqual(oldObj.BinaryData, newObj.BinaryData)
+		case *v1.Secret:
+			oldObj, ok := e.ObjectOld.(*v1.Secret)
+			return !ok || !equality.Semantic.DeepEqual(oldObj.Data, newObj.Data)
+		case *v1.ServiceAccount:
+			// only the metadata is set by the operator, the token secrets are managed by kubernetes
+			return false
+		}
+		return true
+	},
+}
+
+// metadataChanged reports changes of the metadata the operator sets or relies on
+func metadataChanged(oldMeta, newMeta metav1.Object) bool {
+	return !equality.Semantic.DeepEqual(oldMeta.GetLabels(), newMeta.GetLabels()) ||
+		!equality.Semantic.DeepEqual(oldMeta.GetAnnotations(), newMeta.GetAnnotations()) ||
+		!equality.Semantic.DeepEqual(oldMeta.GetOwnerReferences(), newMeta.GetOwnerReferences()) ||
+		oldMeta.GetDeletionTimestamp().IsZero() != newMeta.GetDeletionTimestamp().IsZero()
+}
+
+// rolloutChanged reports changes of the deployment status fields reported by the application status
+func rolloutChanged(oldDeploy, newDeploy *appsv1.Deployment) bool {
+	oldStatus, newStatus := oldDeploy.Status, newDeploy.Status
+	if oldStatus.ObservedGeneration != newStatus.ObservedGeneration ||
+		oldStatus.Replicas != newStatus.Replicas ||
+		oldStatus.ReadyReplicas != newStatus.ReadyReplicas ||
+		oldStatus.UpdatedReplicas != newStatus.UpdatedReplicas ||
+		oldStatus.AvailableReplicas != newStatus.AvailableReplicas ||
+		len(oldStatus.Conditions) != len(newStatus.Conditions) {
+		return true
+	}
+	for i := range newStatus.Conditions {
+		oldCondition, newCondition := oldStatus.Conditions[i], newStatus.Conditions[i]
+		if oldCondition.Type != newCondition.Type ||
+			oldCondition.Status != newCondition.Status ||
+			oldCondition.Reason != newCondition.Reason {
+			return true
+		}
+	}
+	return false
+}
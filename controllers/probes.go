@@ -0,0 +1,81 @@
+/*
+Copyright 2020 qingmu.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	springbootv1alpha1 "spring-boot-operator/api/v1alpha1"
+)
+
+// startupFailureThreshold gives a slow application 30 periods, 5 minutes by default, to start
+const startupFailureThreshold = 30
+
+// applyProbes sets the liveness, readiness and startup probes of the container
+func applyProbes(container *v1.Container, springBoot *springbootv1alpha1.SpringBoot) {
+	port := intstr.FromInt(int(springBoot.ProbePort()))
+	livenessPath, readinessPath := springBoot.Path.Liveness, springBoot.Path.Readiness
+	if springBoot.UseHealthGroups() {
+		// Check leaves the paths unset unless the user set them
+		if livenessPath == "" {
+			livenessPath = springbootv1alpha1.HealthGroupLivenessPath
+		}
+		if readinessPath == "" {
+			readinessPath = springbootv1alpha1.HealthGroupReadinessPath
+		}
+	}
+	probes := springBoot.Probes
+	container.LivenessProbe = httpProbe(livenessPath, port, probes.Liveness)
+	container.ReadinessProbe = httpProbe(readinessPath, port, probes.Readiness)
+	container.StartupProbe = nil
+	if probes.Startup != nil {
+		startup := *probes.Startup
+		if startup.FailureThreshold == 0 {
+			startup.FailureThreshold = startupFailureThreshold
+		}
+		container.StartupProbe = httpProbe(livenessPath, port, startup)
+	}
+}
+
+// httpProbe returns an http get probe. Unset timings get the kubernetes defaults so the
+// deployment is not updated on every reconcile
+func httpProbe(path string, port intstr.IntOrString, spec springbootv1alpha1.ProbeSpec) *v1.Probe {
+	if spec.PeriodSeconds == 0 {
+		spec.PeriodSeconds = 10
+	}
+	if spec.TimeoutSeconds == 0 {
+		spec.TimeoutSeconds = 1
+	}
+	if spec.FailureThreshold == 0 {
+		spec.FailureThreshold = 3
+	}
+	return &v1.Probe{
+		Handler: v1.Handler{
+			HTTPGet: &v1.HTTPGetAction{
+				Path:   path,
+				Port:   port,
+				Scheme: v1.URISchemeHTTP,
+			},
+		},
+		InitialDelaySeconds: spec.InitialDelaySeconds,
+		PeriodSeconds:       spec.PeriodSeconds,
+		TimeoutSeconds:      spec.TimeoutSeconds,
+		FailureThreshold:    spec.FailureThreshold,
+		SuccessThreshold:    1,
+	}
+}
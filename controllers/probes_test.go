@@ -0,0 +1,92 @@
+/*
+Copyright 2020 qingmu.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	springbootv1alpha1 "spring-boot-operator/api/v1alpha1"
+	"spring-boot-operator/global"
+)
+
+func TestApplyProbesPaths(t *testing.T) {
+	global.GetGlobalConfig().LivenessPath = "/actuator/health"
+	global.GetGlobalConfig().ReadinessPath = "/actuator/health"
+	tests := []struct {
+		name              string
+		springBootVersion string
+		path              springbootv1alpha1.PathSpec
+		wantLiveness      string
+		wantReadiness     string
+	}{
+		{"default paths", "2.2.0.RELEASE", springbootv1alpha1.PathSpec{}, "/actuator/health", "/actuator/health"},
+		{"health groups", "2.3.4.RELEASE", springbootv1alpha1.PathSpec{},
+			springbootv1alpha1.HealthGroupLivenessPath, springbootv1alpha1.HealthGroupReadinessPath},
+		{"explicit paths kept with health groups", "2.3.4.RELEASE", springbootv1alpha1.PathSpec{Liveness: "/live", Readiness: "/ready"},
+			"/live", "/ready"},
+		{"explicit liveness path kept with health groups", "2.3.4.RELEASE", springbootv1alpha1.PathSpec{Liveness: "/live"},
+			"/live", springbootv1alpha1.HealthGroupReadinessPath},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			springBoot := &springbootv1alpha1.SpringBoot{Version: "v1", SpringBootVersion: tt.springBootVersion, Path: tt.path}
+			if _, err := springBoot.Check("demo"); err != nil {
+				t.Fatal(err)
+			}
+			container := &v1.Container{}
+			applyProbes(container, springBoot)
+			if got := container.LivenessProbe.HTTPGet.Path; got != tt.wantLiveness {
+				t.Errorf("liveness path = %s, want %s", got, tt.wantLiveness)
+			}
+			if got := container.ReadinessProbe.HTTPGet.Path; got != tt.wantReadiness {
+				t.Errorf("readiness path = %s, want %s", got, tt.wantReadiness)
+			}
+		})
+	}
+}
+
+func TestHealthGroupsAfterUpgrade(t *testing.T) {
+	global.GetGlobalConfig().LivenessPath = "/actuator/health"
+	global.GetGlobalConfig().ReadinessPath = "/actuator/health"
+	app := &springbootv1alpha1.SpringBootApplication{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "demo"},
+		Spec: springbootv1alpha1.SpringBootApplicationSpec{
+			SpringBoot: springbootv1alpha1.SpringBoot{Version: "v1", SpringBootVersion: "2.2.0.RELEASE"},
+		},
+	}
+	// reconciled, then updated to spring boot 2.3. The default paths are never stored in the spec
+	if _, err := app.Spec.SpringBoot.DeepCopy().Check(app.Name); err != nil {
+		t.Fatal(err)
+	}
+	app.Spec.SpringBoot.SpringBootVersion = "2.3.4.RELEASE"
+
+	springBoot, err := app.Spec.SpringBoot.DeepCopy().Check(app.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	container := &v1.Container{}
+	applyProbes(container, springBoot)
+	if got := container.LivenessProbe.HTTPGet.Path; got != springbootv1alpha1.HealthGroupLivenessPath {
+		t.Errorf("liveness path = %s, want %s", got, springbootv1alpha1.HealthGroupLivenessPath)
+	}
+	if got := container.ReadinessProbe.HTTPGet.Path; got != springbootv1alpha1.HealthGroupReadinessPath {
+		t.Errorf("readiness path = %s, want %s", got, springbootv1alpha1.HealthGroupReadinessPath)
+	}
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2020 qingmu.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	springbootv1alpha1 "spring-boot-operator/api/v1alpha1"
+)
+
+// profileIndexField indexes the applications by the name of the profile they reference
+const profileIndexField = ".spec.profileRef"
+
+// profileError is a failed profile resolve, the spec of the application is then unresolved
+type profileError struct {
+	err error
+}
+
+func (e *profileError) Error() string {
+	return e.err.Error()
+}
+
+func (e *profileError) Unwrap() error {
+	return e.err
+}
+
+// resolveProfile merges the spring boot body of the application onto its profile. Like Check,
+// it resolves the in-memory spec, only the status of the application is written back
+func (r *SpringBootApplicationReconciler) resolveProfile(ctx context.Context, app *springbootv1alpha1.SpringBootApplication) error {
+	if app.Spec.ProfileRef == "" {
+		return nil
+	}
+	profile := &springbootv1alpha1.SpringBootProfile{}
+	if err := r.Get(ctx, types.NamespacedName{Name: app.Spec.ProfileRef}, profile); err != nil {
+		return err
+	}
+	springBoot, err := profile.Merge(&app.Spec.SpringBoot)
+	if err != nil {
+		return err
+	}
+	app.Spec.SpringBoot = *springBoot
+	return nil
+}
+
+// indexProfileRef is the index function of profileIndexField
+func indexProfileRef(obj runtime.Object) []string {
+	app, ok := obj.(*springbootv1alpha1.SpringBootApplication)
+	if !ok || app.Spec.ProfileRef == "" {
+		return nil
+	}
+	return []string{app.Spec.ProfileRef}
+}
+
+// profileApplications maps the profiles whose spring boot body uses an object to the applications
+// of the namespace referencing them
+func (r *SpringBootApplicationReconciler) profileApplications(namespace string,
+	uses func(springBoot *springbootv1alpha1.SpringBoot) bool) []reconcile.Request {
+	ctx := context.Background()
+	profiles := &springbootv1alpha1.SpringBootProfileList{}
+	if err := r.List(ctx, profiles); err != nil {
+		r.Log.Error(err, "List profiles failed")
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, profile := range profiles.Items {
+		if !uses(&profile.Spec.SpringBoot) {
+			continue
+		}
+		apps := &springbootv1alpha1.SpringBootApplicationList{}
+		if err := r.List(ctx, apps, client.InNamespace(namespace), client.MatchingField(profileIndexField, profile.Name)); err != nil {
+			r.Log.Error(err, "List referencing applications failed", "field", profileIndexField, "name", profile.Name)
+			continue
+		}
+		for _, app := range apps.Items {
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{Namespace: app.Namespace, Name: app.Name},
+			})
+		}
+	}
+	return requests
+}
+
+// uniqueRequests drops the repeated requests, keeping the order
+func uniqueRequests(requests []reconcile.Request) []reconcile.Request {
+	unique := make([]reconcile.Request, 0, len(requests))
+	seen := map[reconcile.Request]bool{}
+	for _, request := range requests {
+		if !seen[request] {
+			seen[request] = true
+			unique = append(unique, request)
+		}
+	}
+	return unique
+}
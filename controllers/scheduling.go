@@ -0,0 +1,109 @@
+/*
+Copyright 2020 qingmu.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	springbootv1alpha1 "spring-boot-operator/api/v1alpha1"
+)
+
+const hostnameTopologyKey = "kubernetes.io/hostname"
+
+// applyScheduling sets the node selector, tolerations, affinity and topology spread constraints of the pod
+func applyScheduling(podSpec *v1.PodSpec, springBoot *springbootv1alpha1.SpringBoot, labels map[string]string) {
+	podSpec.NodeSelector = springBoot.NodeSelector
+	podSpec.Tolerations = springBoot.Tolerations
+
+	affinity := &v1.Affinity{
+		NodeAffinity:    nodeAffinity(&springBoot.NodeAffinity),
+		PodAntiAffinity: podAntiAffinity(springBoot.PodAntiAffinity, labels["k8s-app"]),
+	}
+	podSpec.Affinity = nil
+	if affinity.NodeAffinity != nil || affinity.PodAntiAffinity != nil {
+		podSpec.Affinity = affinity
+	}
+
+	podSpec.TopologySpreadConstraints = nil
+	for _, constraint := range springBoot.TopologySpreadConstraints {
+		if constraint.LabelSelector == nil {
+			constraint.LabelSelector = &metav1.LabelSelector{MatchLabels: labels}
+		}
+		podSpec.TopologySpreadConstraints = append(podSpec.TopologySpreadConstraints, constraint)
+	}
+}
+
+// nodeAffinity returns nil when no node affinity is set. The single key requirement
+// is added to every required term, or is the only term when there is none
+func nodeAffinity(spec *springbootv1alpha1.NodeAffinitySpec) *v1.NodeAffinity {
+	terms := make([]v1.NodeSelectorTerm, 0, len(spec.Required)+1)
+	for _, term := range spec.Required {
+		terms = append(terms, *term.DeepCopy())
+	}
+	if spec.Key != "" {
+		requirement := v1.NodeSelectorRequirement{
+			Key:      spec.Key,
+			Operator: v1.NodeSelectorOperator(spec.Operator),
+			Values:   spec.Values,
+		}
+		if len(terms) == 0 {
+			terms = append(terms, v1.NodeSelectorTerm{})
+		}
+		for i := range terms {
+			terms[i].MatchExpressions = append(terms[i].MatchExpressions, requirement)
+		}
+	}
+	if len(terms) == 0 && len(spec.Preferred) == 0 {
+		return nil
+	}
+	affinity := &v1.NodeAffinity{PreferredDuringSchedulingIgnoredDuringExecution: spec.Preferred}
+	if len(terms) > 0 {
+		affinity.RequiredDuringSchedulingIgnoredDuringExecution = &v1.NodeSelector{NodeSelectorTerms: terms}
+	}
+	return affinity
+}
+
+// podAntiAffinity keeps the pods of the application on different nodes
+func podAntiAffinity(mode string, name string) *v1.PodAntiAffinity {
+	term := v1.PodAffinityTerm{
+		TopologyKey: hostnameTopologyKey,
+		LabelSelector: &metav1.LabelSelector{
+			MatchExpressions: []metav1.LabelSelectorRequirement{
+				{
+					Key:      "k8s-app",
+					Operator: metav1.LabelSelectorOpIn,
+					Values:   []string{name},
+				},
+			},
+		},
+	}
+	switch mode {
+	case springbootv1alpha1.PodAntiAffinityOff:
+		return nil
+	case springbootv1alpha1.PodAntiAffinityHard:
+		return &v1.PodAntiAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: []v1.PodAffinityTerm{term},
+		}
+	default:
+		return &v1.PodAntiAffinity{
+			PreferredDuringSchedulingIgnoredDuringExecution: []v1.WeightedPodAffinityTerm{
+				{Weight: 1, PodAffinityTerm: term},
+			},
+		}
+	}
+}
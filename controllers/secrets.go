@@ -0,0 +1,163 @@
+/*
+Copyright 2020 qingmu.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	springbootv1alpha1 "spring-boot-operator/api/v1alpha1"
+	"spring-boot-operator/global"
+)
+
+const (
+	secretHashAnnotation = "springboot.qingmu.io/secret-hash"
+	// secretIndexField indexes the applications by the names of the secrets they reference
+	secretIndexField = ".spec.springBoot.secrets"
+)
+
+// referencedSecrets returns the names of the secrets used by the application, mapped to
+// whether the secret must exist. Optional env references may point at a missing secret
+func referencedSecrets(springBoot *springbootv1alpha1.SpringBoot) map[string]bool {
+	secrets := map[string]bool{}
+	for _, secret := range springBoot.Secrets {
+		secrets[secret.Name] = true
+	}
+	for _, env := range springBoot.Env {
+		if env.ValueFrom == nil || env.ValueFrom.SecretKeyRef == nil {
+			continue
+		}
+		ref := env.ValueFrom.SecretKeyRef
+		required := ref.Optional == nil || !*ref.Optional
+		secrets[ref.Name] = secrets[ref.Name] || required
+	}
+	return secrets
+}
+
+// indexSecretNames is the index function of secretIndexField
+func indexSecretNames(obj runtime.Object) []string {
+	app, ok := obj.(*springbootv1alpha1.SpringBootApplication)
+	if !ok {
+		return nil
+	}
+	names := []string{}
+	for name := range referencedSecrets(&app.Spec.SpringBoot) {
+		names = append(names, name)
+	}
+	return names
+}
+
+// envReferencesSecret reports whether a value of the env is read from the secret
+func envReferencesSecret(env []v1.EnvVar, name string) bool {
+	for _, e := range env {
+		if e.ValueFrom != nil && e.ValueFrom.SecretKeyRef != nil && e.ValueFrom.SecretKeyRef.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// secretApplications maps a secret to the applications using it. The index only holds the secrets
+// of the application spec, the secrets of the operator, namespace and profile env are looked up here
+func (r *SpringBootApplicationReconciler) secretApplications(a handler.MapObject) []reconcile.Request {
+	ctx := context.Background()
+	namespace, name := a.Meta.GetNamespace(), a.Meta.GetName()
+	requests := r.referencingApplications(secretIndexField)(a)
+
+	usedByDefaults := envReferencesSecret(global.GetGlobalConfig().Env, name)
+	if !usedByDefaults {
+		namespaceDefaults, err := r.namespaceDefaults(ctx, namespace)
+		if err != nil {
+			r.Log.Error(err, "List namespace defaults failed", "namespace", namespace)
+		}
+		for _, defaults := range namespaceDefaults {
+			usedByDefaults = usedByDefaults || envReferencesSecret(defaults.Env, name)
+		}
+	}
+	if usedByDefaults {
+		requests = append(requests, r.namespaceApplications(a)...)
+	}
+
+	requests = append(requests, r.profileApplications(namespace, func(springBoot *springbootv1alpha1.SpringBoot) bool {
+		_, ok := referencedSecrets(springBoot)[name]
+		return ok
+	})...)
+	return uniqueRequests(requests)
+}
+
+// hashSecrets returns a hash of the content of every secret used by the application,
+// an empty string when the application uses no secret
+func (r *SpringBootApplicationReconciler) hashSecrets(ctx context.Context, namespace string,
+	springBoot *springbootv1alpha1.SpringBoot) (string, error) {
+	secrets := referencedSecrets(springBoot)
+	if len(secrets) == 0 {
+		return "", nil
+	}
+	names := make([]string, 0, len(secrets))
+	for name := range secrets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	hashes := map[string]string{}
+	for _, name := range names {
+		secret := &v1.Secret{}
+		if err := r.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, secret); err != nil {
+			if apierrors.IsNotFound(err) && !secrets[name] {
+				continue
+			}
+			return "", err
+		}
+		hashes[name] = hashData(nil, secret.Data)
+	}
+	return hashData(hashes, nil), nil
+}
+
+// applySecrets mounts the secrets into the pod or injects them as env of the container
+func applySecrets(podSpec *v1.PodSpec, container *v1.Container, secrets []springbootv1alpha1.SecretSpec) {
+	for i, secret := range secrets {
+		if secret.MountPath == "" {
+			container.EnvFrom = append(container.EnvFrom, v1.EnvFromSource{
+				Prefix: secret.EnvPrefix,
+				SecretRef: &v1.SecretEnvSource{
+					LocalObjectReference: v1.LocalObjectReference{Name: secret.Name},
+				},
+			})
+			continue
+		}
+		volumeName := fmt.Sprintf("secret-%d", i)
+		podSpec.Volumes = append(podSpec.Volumes, v1.Volume{
+			Name: volumeName,
+			VolumeSource: v1.VolumeSource{
+				Secret: &v1.SecretVolumeSource{SecretName: secret.Name},
+			},
+		})
+		container.VolumeMounts = append(container.VolumeMounts, v1.VolumeMount{
+			Name:      volumeName,
+			ReadOnly:  true,
+			MountPath: secret.MountPath,
+		})
+	}
+}
@@ -0,0 +1,125 @@
+/*
+Copyright 2020 qingmu.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+
+	springbootv1alpha1 "spring-boot-operator/api/v1alpha1"
+	"spring-boot-operator/global"
+)
+
+func secretEnv(name, secret string) v1.EnvVar {
+	return v1.EnvVar{Name: name, ValueFrom: &v1.EnvVarSource{SecretKeyRef: &v1.SecretKeySelector{
+		LocalObjectReference: v1.LocalObjectReference{Name: secret},
+		Key:                  "value",
+	}}}
+}
+
+var _ = Describe("Mapping a secret to the applications using it", func() {
+	const namespace = "secret-mapping"
+	var ctx context.Context
+	var reconciler *SpringBootApplicationReconciler
+	var stop chan struct{}
+
+	BeforeEach(func() {
+		requireEnvtest()
+		ctx = context.Background()
+		mgr, err := ctrl.NewManager(cfg, ctrl.Options{Scheme: scheme.Scheme, MetricsBindAddress: "0"})
+		Expect(err).NotTo(HaveOccurred())
+		reconciler = &SpringBootApplicationReconciler{
+			Client:   mgr.GetClient(),
+			Log:      ctrl.Log.WithName("controllers").WithName("SpringBootApplication"),
+			Scheme:   mgr.GetScheme(),
+			Recorder: mgr.GetEventRecorderFor("spring-boot-operator"),
+		}
+		// the indexes, the applications are not reconciled
+		Expect(reconciler.SetupWithManager(mgr)).To(Succeed())
+		stop = make(chan struct{})
+		go func() {
+			defer GinkgoRecover()
+			Expect(mgr.Start(stop)).To(Succeed())
+		}()
+	})
+
+	AfterEach(func() {
+		if stop != nil {
+			close(stop)
+		}
+		global.GetGlobalConfig().Env = nil
+	})
+
+	mapped := func(secret string) func() []string {
+		return func() []string {
+			var names []string
+			object := &v1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: secret}}
+			for _, request := range reconciler.secretApplications(handler.MapObject{Meta: object, Object: object}) {
+				names = append(names, request.Name)
+			}
+			sort.Strings(names)
+			return names
+		}
+	}
+
+	It("follows the env of the applications, the operator, the namespace defaults and the profiles", func() {
+		Expect(k8sClient.Create(ctx, &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace}})).To(Succeed())
+		Expect(k8sClient.Create(ctx, &springbootv1alpha1.SpringBootProfile{
+			ObjectMeta: metav1.ObjectMeta{Name: "secret-mapping"},
+			Spec: springbootv1alpha1.SpringBootProfileSpec{SpringBoot: springbootv1alpha1.SpringBoot{
+				Env: []v1.EnvVar{secretEnv("PROFILE", "profile-secret")},
+			}},
+		})).To(Succeed())
+		Expect(k8sClient.Create(ctx, &springbootv1alpha1.SpringBootApplication{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "plain"},
+			Spec: springbootv1alpha1.SpringBootApplicationSpec{SpringBoot: springbootv1alpha1.SpringBoot{
+				Version: "v1", Env: []v1.EnvVar{secretEnv("APP", "app-secret")},
+			}},
+		})).To(Succeed())
+		Expect(k8sClient.Create(ctx, &springbootv1alpha1.SpringBootApplication{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "profiled"},
+			Spec: springbootv1alpha1.SpringBootApplicationSpec{
+				ProfileRef: "secret-mapping",
+				SpringBoot: springbootv1alpha1.SpringBoot{Version: "v1"},
+			},
+		})).To(Succeed())
+
+		Eventually(mapped("app-secret"), 10*time.Second).Should(Equal([]string{"plain"}))
+		Eventually(mapped("profile-secret"), 10*time.Second).Should(Equal([]string{"profiled"}))
+		Expect(mapped("defaults-secret")()).To(BeEmpty())
+
+		By("referencing the secret from the namespace defaults")
+		Expect(k8sClient.Create(ctx, &springbootv1alpha1.SpringBootDefaults{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "team"},
+			Spec:       springbootv1alpha1.SpringBootDefaultsSpec{Env: []v1.EnvVar{secretEnv("TEAM", "defaults-secret")}},
+		})).To(Succeed())
+		Eventually(mapped("defaults-secret"), 10*time.Second).Should(Equal([]string{"plain", "profiled"}))
+
+		By("referencing the secret from the operator env")
+		global.GetGlobalConfig().Env = global.EnvVars{secretEnv("GLOBAL", "global-secret")}
+		Expect(mapped("global-secret")()).To(Equal([]string{"plain", "profiled"}))
+	})
+})
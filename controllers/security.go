@@ -0,0 +1,115 @@
+/*
+Copyright 2020 qingmu.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	springbootv1alpha1 "spring-boot-operator/api/v1alpha1"
+)
+
+const tmpVolumeName = "tmp"
+
+// reconcileServiceAccount creates the service account of the application when requested,
+// and deletes the accounts it created before under another name or while it was requested
+func (r *SpringBootApplicationReconciler) reconcileServiceAccount(ctx context.Context, log logr.Logger,
+	app *springbootv1alpha1.SpringBootApplication, springBoot *springbootv1alpha1.SpringBoot, meta metav1.ObjectMeta) error {
+	name := ""
+	if springBoot.Security.CreateServiceAccount {
+		name = springBoot.ServiceAccountName(meta.Name)
+	}
+	accounts := &v1.ServiceAccountList{}
+	if err := r.List(ctx, accounts, client.InNamespace(meta.Namespace), client.MatchingLabels(meta.Labels)); err != nil {
+		return err
+	}
+	for i := range accounts.Items {
+		account := &accounts.Items[i]
+		if account.Name == name {
+			continue
+		}
+		deleted, err := r.deleteOwned(ctx, app, account)
+		if deleted {
+			log.Info("delete " + account.Name + " service account")
+			r.Recorder.Eventf(app, v1.EventTypeNormal, "ServiceAccountDeleted", "ServiceAccount %s deleted", account.Name)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if name == "" {
+		return nil
+	}
+
+	meta.Name = name
+	account := &v1.ServiceAccount{ObjectMeta: meta}
+	op, err := r.applyOwned(ctx, app, account)
+	if err != nil {
+		return err
+	}
+	log.Info(string(op) + " " + meta.Name + " service account")
+	r.recordOperation(app, "ServiceAccount", op)
+	return nil
+}
+
+// applySecurity sets the service account and the security context of the pod
+func applySecurity(podSpec *v1.PodSpec, container *v1.Container, springBoot *springbootv1alpha1.SpringBoot,
+	name string, annotations map[string]string) {
+	security := springBoot.Security
+	podSpec.ServiceAccountName = springBoot.ServiceAccountName(name)
+	podSpec.AutomountServiceAccountToken = security.AutomountServiceAccountToken
+
+	if security.RunAsNonRoot != nil || security.RunAsUser != nil || security.FSGroup != nil {
+		podSpec.SecurityContext = &v1.PodSecurityContext{
+			RunAsNonRoot: security.RunAsNonRoot,
+			RunAsUser:    security.RunAsUser,
+			FSGroup:      security.FSGroup,
+		}
+	}
+	if security.SeccompProfile != "" {
+		annotations[v1.SeccompPodAnnotationKey] = security.SeccompProfile
+	}
+
+	readOnly := security.ReadOnlyRootFilesystem != nil && *security.ReadOnlyRootFilesystem
+	var dropCapabilities []v1.Capability
+	if security.DropCapabilities != nil {
+		dropCapabilities = *security.DropCapabilities
+	}
+	if !readOnly && len(dropCapabilities) == 0 {
+		return
+	}
+	container.SecurityContext = &v1.SecurityContext{}
+	if len(dropCapabilities) > 0 {
+		container.SecurityContext.Capabilities = &v1.Capabilities{Drop: dropCapabilities}
+	}
+	if readOnly {
+		container.SecurityContext.ReadOnlyRootFilesystem = security.ReadOnlyRootFilesystem
+		// the jvm and the embedded tomcat write their temporary files into /tmp
+		podSpec.Volumes = append(podSpec.Volumes, v1.Volume{
+			Name:         tmpVolumeName,
+			VolumeSource: v1.VolumeSource{EmptyDir: &v1.EmptyDirVolumeSource{}},
+		})
+		container.VolumeMounts = append(container.VolumeMounts, v1.VolumeMount{
+			Name:      tmpVolumeName,
+			MountPath: "/tmp",
+		})
+	}
+}
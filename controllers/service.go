@@ -0,0 +1,93 @@
+/*
+Copyright 2020 qingmu.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	springbootv1alpha1 "spring-boot-operator/api/v1alpha1"
+)
+
+// mutateServiceSpec sets the fields of the service spec the operator owns. Values allocated
+// by kubernetes (cluster ip, node ports) are left unset, so the applied service keeps them
+func mutateServiceSpec(service *v1.Service, name string, springBoot *springbootv1alpha1.SpringBoot) {
+	spec := springBoot.Service
+
+	service.Annotations = spec.Annotations
+
+	switch spec.Type {
+	case springbootv1alpha1.ServiceTypeNodePort:
+		service.Spec.Type = v1.ServiceTypeNodePort
+	case springbootv1alpha1.ServiceTypeLoadBalancer:
+		service.Spec.Type = v1.ServiceTypeLoadBalancer
+	default:
+		service.Spec.Type = v1.ServiceTypeClusterIP
+	}
+	if spec.Type == springbootv1alpha1.ServiceTypeHeadless {
+		service.Spec.ClusterIP = v1.ClusterIPNone
+	} else if springBoot.ClusterIp != "" {
+		service.Spec.ClusterIP = springBoot.ClusterIp
+	}
+
+	exposesNodePorts := service.Spec.Type != v1.ServiceTypeClusterIP
+	ports := []v1.ServicePort{
+		{
+			Name:       name,
+			Port:       springBoot.Port,
+			TargetPort: intstr.FromInt(int(springBoot.Port)),
+			Protocol:   v1.ProtocolTCP,
+			NodePort:   spec.NodePort,
+		},
+	}
+	for _, port := range spec.Ports {
+		servicePort := v1.ServicePort{
+			Name:       port.Name,
+			Port:       port.Port,
+			TargetPort: port.TargetPort,
+			Protocol:   port.Protocol,
+			NodePort:   port.NodePort,
+		}
+		if servicePort.TargetPort.Type == intstr.Int && servicePort.TargetPort.IntVal == 0 {
+			servicePort.TargetPort = intstr.FromInt(int(port.Port))
+		}
+		if servicePort.Protocol == "" {
+			servicePort.Protocol = v1.ProtocolTCP
+		}
+		ports = append(ports, servicePort)
+	}
+	if !exposesNodePorts {
+		for i := range ports {
+			ports[i].NodePort = 0
+		}
+	}
+	service.Spec.Ports = ports
+
+	service.Spec.SessionAffinity = spec.SessionAffinity
+	if service.Spec.SessionAffinity == "" {
+		service.Spec.SessionAffinity = v1.ServiceAffinityNone
+	}
+	if service.Spec.SessionAffinity != v1.ServiceAffinityClientIP {
+		service.Spec.SessionAffinityConfig = nil
+	}
+	if exposesNodePorts {
+		service.Spec.ExternalTrafficPolicy = spec.ExternalTrafficPolicy
+		if service.Spec.ExternalTrafficPolicy == "" {
+			service.Spec.ExternalTrafficPolicy = v1.ServiceExternalTrafficPolicyTypeCluster
+		}
+	}
+}
@@ -0,0 +1,65 @@
+/*
+Copyright 2020 qingmu.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"strconv"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	springbootv1alpha1 "spring-boot-operator/api/v1alpha1"
+)
+
+// applyShutdown sets the preStop hook and the termination grace period of the pod and
+// enables the spring boot graceful shutdown, the env set by the user wins
+func applyShutdown(podSpec *v1.PodSpec, container *v1.Container, springBoot *springbootv1alpha1.SpringBoot) {
+	shutdown := springBoot.Shutdown
+	switch shutdown.Mode {
+	case springbootv1alpha1.ShutdownModeNone:
+		container.Lifecycle = nil
+	case springbootv1alpha1.ShutdownModeSleep:
+		container.Lifecycle = &v1.Lifecycle{
+			PreStop: &v1.Handler{
+				Exec: &v1.ExecAction{
+					Command: []string{"sleep", strconv.Itoa(int(springBoot.ShutdownSleepSeconds()))},
+				},
+			},
+		}
+	default:
+		container.Lifecycle = &v1.Lifecycle{
+			PreStop: &v1.Handler{
+				HTTPGet: &v1.HTTPGetAction{
+					Path:   springBoot.Path.Shutdown,
+					Port:   intstr.FromInt(int(springBoot.ProbePort())),
+					Scheme: v1.URISchemeHTTP,
+				},
+			},
+		}
+	}
+
+	if shutdown.GracefulTimeoutSeconds > 0 {
+		container.Env = setDefaultEnv(container.Env, v1.EnvVar{Name: "SERVER_SHUTDOWN", Value: "graceful"})
+		container.Env = setDefaultEnv(container.Env, v1.EnvVar{
+			Name:  "SPRING_LIFECYCLE_TIMEOUT_PER_SHUTDOWN_PHASE",
+			Value: strconv.Itoa(int(shutdown.GracefulTimeoutSeconds)) + "s",
+		})
+	}
+
+	terminationGracePeriodSeconds := springBoot.TerminationGracePeriodSeconds()
+	podSpec.TerminationGracePeriodSeconds = &terminationGracePeriodSeconds
+}
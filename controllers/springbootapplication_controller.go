@@ -19,11 +19,20 @@ package controllers
 import (
 	"context"
 	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2beta2 "k8s.io/api/autoscaling/v2beta2"
 	v1 "k8s.io/api/core/v1"
-	"k8s.io/apimachinery/pkg/api/resource"
+	networkingv1beta1 "k8s.io/api/networking/v1beta1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 	"strconv"
 
 	"github.com/go-logr/logr"
@@ -37,29 +46,72 @@ import (
 // SpringBootApplicationReconciler reconciles a SpringBootApplication object
 type SpringBootApplicationReconciler struct {
 	client.Client
-	Log    logr.Logger
-	Scheme *runtime.Scheme
+	Log      logr.Logger
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+	// Requeues the applications when the operator configuration changed, see GlobalConfigReconciler
+	ConfigChanges <-chan event.GenericEvent
+	// The field manager of the generated objects updated before they were applied, its fields
+	// are handed over to the apply field manager. Empty disables the hand over
+	LegacyFieldManager string
 }
 
 // +kubebuilder:rbac:groups=springboot.qingmu.io,resources=springbootapplications,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=springboot.qingmu.io,resources=springbootapplications/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=springboot.qingmu.io,resources=springbootdefaults,verbs=get;list;watch
+// +kubebuilder:rbac:groups=springboot.qingmu.io,resources=springbootprofiles,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+// +kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=services,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=autoscaling,resources=horizontalpodautoscalers,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=policy,resources=poddisruptionbudgets,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=networking.k8s.io,resources=ingresses,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=serviceaccounts,verbs=get;list;watch;create;update;patch;delete
 
 func (r *SpringBootApplicationReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 	ctx := context.Background()
 	log := r.Log.WithValues("springbootapplication", req.NamespacedName)
-	c := r.Client
 
 	app := &springbootv1alpha1.SpringBootApplication{}
 	err := r.Get(ctx, req.NamespacedName, app)
 	if err != nil {
-		log.Info(req.NamespacedName.Name + " is deleted .")
-		return ctrl.Result{}, nil
+		if apierrors.IsNotFound(err) {
+			log.Info(req.NamespacedName.Name + " is deleted .")
+			return ctrl.Result{}, nil
+		}
+		// transient errors are returned so the request is requeued with backoff
+		return ctrl.Result{}, err
 	}
 	name := app.GetObjectMeta().GetName()
-	springBoot, err := app.Spec.SpringBoot.Check(name)
+	if err := r.resolveProfile(ctx, app); err != nil {
+		log.Error(err, "Profile resolve failed")
+		r.Recorder.Event(app, v1.EventTypeWarning, "ProfileFailed", err.Error())
+		if apierrors.IsNotFound(err) {
+			// the profile watch triggers a reconcile once the profile is created
+			return ctrl.Result{}, r.reportStatus(ctx, log, app, nil, nil, &profileError{err: err})
+		}
+		r.reportStatus(ctx, log, app, nil, nil, &profileError{err: err})
+		return ctrl.Result{}, err
+	}
+	defaults, err := r.namespaceDefaults(ctx, req.Namespace)
+	if err != nil {
+		log.Error(err, "List SpringBootDefaults failed")
+		return ctrl.Result{}, err
+	}
+	springBoot, err := app.Spec.SpringBoot.Check(name, defaults...)
 	if err != nil {
 		log.Error(err, "check err ")
-		return ctrl.Result{}, nil
+		r.Recorder.Event(app, v1.EventTypeWarning, "CheckFailed", err.Error())
+		// a spec error is not retried, the next change of the application triggers a reconcile
+		return ctrl.Result{}, r.reportStatus(ctx, log, app, nil, nil, err)
+	}
+	// invalid values must never reach the mutate functions below, a bad spec only stops its own application
+	resources, err := r.validateSpec(app, springBoot)
+	if err != nil {
+		log.Error(err, "Invalid spec")
+		return ctrl.Result{}, r.reportStatus(ctx, log, app, nil, nil, err)
 	}
 	log.Info("Received spring boot app,service is [" + name + ":" + strconv.Itoa(int(springBoot.Port)) + "], image is [" + springBoot.Image + "] ")
 	// mate data
@@ -72,218 +124,300 @@ func (r *SpringBootApplicationReconciler) Reconcile(req ctrl.Request) (ctrl.Resu
 		Labels:    labels,
 	}
 
-	service := &v1.Service{ObjectMeta: meta}
+	config, err := r.reconcileConfig(ctx, log, app, springBoot, meta)
+	if err != nil {
+		log.Error(err, "ConfigMap reconcile failed")
+		r.Recorder.Event(app, v1.EventTypeWarning, "ConfigMapFailed", err.Error())
+		r.reportStatus(ctx, log, app, nil, nil, err)
+		return ctrl.Result{}, err
+	}
 
-	// Create or Update the Service
-	//r.Get(ctx,req.NamespacedName,service)
+	secretHash, err := r.hashSecrets(ctx, req.Namespace, springBoot)
+	if err != nil {
+		log.Error(err, "Secret lookup failed")
+		r.Recorder.Event(app, v1.EventTypeWarning, "SecretFailed", err.Error())
+		r.reportStatus(ctx, log, app, nil, nil, err)
+		return ctrl.Result{}, err
+	}
 
-	if err = controllerutil.SetControllerReference(app, service, r.Scheme); err != nil {
+	if err := r.reconcileServiceAccount(ctx, log, app, springBoot, meta); err != nil {
+		log.Error(err, "ServiceAccount reconcile failed")
+		r.Recorder.Event(app, v1.EventTypeWarning, "ServiceAccountFailed", err.Error())
+		r.reportStatus(ctx, log, app, nil, nil, err)
 		return ctrl.Result{}, err
 	}
-	if op, err := controllerutil.CreateOrUpdate(ctx, c, service, func() error {
-		// Deployment selector is immutable so we set this value only if
-		// a new object is going to be created
-		if service.ObjectMeta.CreationTimestamp.IsZero() {
-			service.Spec.Selector = labels
-		}
 
-		//service.Spec = v1.ServiceSpec{
-		//	Ports: []v1.ServicePort{
-		//		{
-		//			Name: name,
-		//			Port: springBoot.Port,
-		//		},
-		//	},
-		//	Selector: labels,
-		//}
-		service.Spec.Selector = labels
-		service.Spec.Ports = []v1.ServicePort{
-			{
-				Name: name,
-				Port: springBoot.Port,
-			},
-		}
-		if springBoot.ClusterIp != "" {
-			service.Spec.ClusterIP = springBoot.ClusterIp
-		}
-		return nil
-	}); err != nil {
-		log.Error(err, "Deployment reconcile failed")
-		return ctrl.Result{}, nil
+	service := &v1.Service{ObjectMeta: meta}
+	service.Spec.Selector = labels
+	mutateServiceSpec(service, name, springBoot)
+	if op, err := r.applyOwned(ctx, app, service); err != nil {
+		log.Error(err, "Service reconcile failed")
+		r.Recorder.Event(app, v1.EventTypeWarning, "ServiceFailed", err.Error())
+		r.reportStatus(ctx, log, app, service, nil, err)
+		return ctrl.Result{}, err
 	} else {
 		log.Info(string(op) + "  service success " + name)
+		r.recordOperation(app, "Service", op)
 	}
 
-	deploy := &appsv1.Deployment{ObjectMeta: meta}
-	// Create or Update the deployment
-	if err := controllerutil.SetControllerReference(app, deploy, r.Scheme); err != nil {
-		return ctrl.Result{}, err
+	// pod
+	containerPorts := []v1.ContainerPort{{ContainerPort: springBoot.Port, Protocol: v1.ProtocolTCP}}
+	if springBoot.ManagementPort != 0 && springBoot.ManagementPort != springBoot.Port {
+		containerPorts = append(containerPorts, v1.ContainerPort{Name: "management", ContainerPort: springBoot.ManagementPort, Protocol: v1.ProtocolTCP})
 	}
-	if op, err := controllerutil.CreateOrUpdate(ctx, c, deploy, func() error {
 
-		// Deployment selector is immutable so we set this value only if
-		// a new object is going to be created
-		selector := &metav1.LabelSelector{
-			MatchLabels: labels,
-		}
-		if deploy.ObjectMeta.CreationTimestamp.IsZero() {
-			deploy.Spec.Selector = selector
-		}
-
-		// update the Deployment pod template
-		// pod
-		port := intstr.IntOrString{IntVal: springBoot.Port}
-
-		limitRlist := v1.ResourceList{
-			"memory": resource.MustParse(springBoot.Resource.Memory.Limit),
-		}
-		if springBoot.Resource.Cpu.Limit != "" {
-			limitRlist["cpu"] = resource.MustParse(springBoot.Resource.Cpu.Limit)
-		}
-		ShareProcessNamespace := true
-		podSpec := &v1.PodSpec{
-			ShareProcessNamespace: &ShareProcessNamespace,
-			Affinity: &v1.Affinity{
-				PodAntiAffinity: &v1.PodAntiAffinity{
-					PreferredDuringSchedulingIgnoredDuringExecution: []v1.WeightedPodAffinityTerm{
-						{Weight: 1,
-							PodAffinityTerm: v1.PodAffinityTerm{
-								TopologyKey: "kubernetes.io/hostname",
-								LabelSelector: &metav1.LabelSelector{
-									MatchExpressions: []metav1.LabelSelectorRequirement{
-										{
-											Key:      "k8s-app",
-											Operator: "In",
-											Values:   []string{name},
-										},
-									},
-								},
-							},
-						},
-					},
-				},
-			},
-			Containers: []v1.Container{
-				{
-					Name:            name,
-					Image:           springBoot.Image,
-					ImagePullPolicy: "IfNotPresent",
-					Ports:           []v1.ContainerPort{{ContainerPort: springBoot.Port}},
-					Env:             springBoot.Env,
-					Resources: v1.ResourceRequirements{
-						Requests: v1.ResourceList{
-							"cpu":    resource.MustParse(springBoot.Resource.Cpu.Request),
-							"memory": resource.MustParse(springBoot.Resource.Memory.Request),
-						},
-						Limits: limitRlist,
-					},
-					Lifecycle: &v1.Lifecycle{
-						PreStop: &v1.Handler{
-							HTTPGet: &v1.HTTPGetAction{
-								Path: springBoot.Path.Shutdown,
-								Port: port,
-							},
-						},
-					},
-					LivenessProbe: &v1.Probe{
-						Handler: v1.Handler{
-							HTTPGet: &v1.HTTPGetAction{
-								Path: springBoot.Path.Liveness,
-								Port: port,
-							},
-						},
-					},
-					ReadinessProbe: &v1.Probe{
-						Handler: v1.Handler{
-							HTTPGet: &v1.HTTPGetAction{
-								Path: springBoot.Path.Readiness,
-								Port: port,
-							},
-						},
-					},
-				},
+	ShareProcessNamespace := true
+	if springBoot.ShareProcessNamespace != nil {
+		ShareProcessNamespace = *springBoot.ShareProcessNamespace
+	}
+	podSpec := &v1.PodSpec{
+		ShareProcessNamespace: &ShareProcessNamespace,
+		InitContainers:        append([]v1.Container(nil), springBoot.InitContainers...),
+		Containers: []v1.Container{
+			{
+				Name:            name,
+				Image:           springBoot.Image,
+				ImagePullPolicy: "IfNotPresent",
+				Ports:           containerPorts,
+				Env:             springBoot.Env,
+				Resources:       resources,
 			},
-		}
+		},
+	}
+	applyProbes(&podSpec.Containers[0], springBoot)
+	applyJvm(&podSpec.Containers[0], springBoot.Jvm, resources)
+	applyShutdown(podSpec, &podSpec.Containers[0], springBoot)
 
-		if len(springBoot.ImagePullSecrets) > 0 {
-			references := []v1.LocalObjectReference{}
-			for _, secret := range springBoot.ImagePullSecrets {
-				references = append(references, v1.LocalObjectReference{Name: secret})
-			}
-			podSpec.ImagePullSecrets = references
+	if len(springBoot.ImagePullSecrets) > 0 {
+		references := []v1.LocalObjectReference{}
+		for _, secret := range springBoot.ImagePullSecrets {
+			references = append(references, v1.LocalObjectReference{Name: secret})
 		}
+		podSpec.ImagePullSecrets = references
+	}
 
-		nodeAffinity := springBoot.NodeAffinity
-		if nodeAffinity.Key != "" {
-			podSpec.Affinity.NodeAffinity = &v1.NodeAffinity{
-				RequiredDuringSchedulingIgnoredDuringExecution: &v1.NodeSelector{
-					NodeSelectorTerms: []v1.NodeSelectorTerm{
-						{
-							MatchExpressions: []v1.NodeSelectorRequirement{
-								{
-									Key:      nodeAffinity.Key,
-									Operator: v1.NodeSelectorOperator(nodeAffinity.Operator),
-									Values:   nodeAffinity.Values,
-								},
-							},
-						},
-					},
-				},
-			}
-		}
+	applyScheduling(podSpec, springBoot, labels)
 
-		hostLog := springBoot.Path.HostLog
-		if hostLog != "" {
-			volumeName := "applogpath"
-			hostPathType := new(v1.HostPathType)
-			*hostPathType = v1.HostPathDirectoryOrCreate
-			podSpec.Volumes = []v1.Volume{
-				{
-					Name: volumeName,
-					VolumeSource: v1.VolumeSource{
-						HostPath: &v1.HostPathVolumeSource{
-							Path: hostLog,
-							Type: hostPathType,
-						},
-					},
-				},
-			}
-			container := &podSpec.Containers[0]
-			container.VolumeMounts = []v1.VolumeMount{{
-				Name:      volumeName,
-				ReadOnly:  false,
-				MountPath: hostLog,
-			}}
+	applyVolumes(podSpec, &podSpec.Containers[0], springBoot)
 
-		}
+	podAnnotations := map[string]string{}
+	if config != nil {
+		config.apply(podSpec, &podSpec.Containers[0], podAnnotations)
+	}
+	applySecrets(podSpec, &podSpec.Containers[0], springBoot.Secrets)
+	if secretHash != "" {
+		podAnnotations[secretHashAnnotation] = secretHash
+	}
+	applySecurity(podSpec, &podSpec.Containers[0], springBoot, name, podAnnotations)
+	// the sidecars come after the application container, which stays the first one
+	podSpec.Containers = append(podSpec.Containers, springBoot.Sidecars...)
+	defaultPortProtocols(podSpec)
+	templateMeta := meta
+	templateMeta.Annotations = podAnnotations
 
-		revisionHistoryLimit := int32(10)
-		deploy.Spec = appsv1.DeploymentSpec{
+	revisionHistoryLimit := int32(10)
+	deploy := &appsv1.Deployment{
+		ObjectMeta: meta,
+		Spec: appsv1.DeploymentSpec{
 			Replicas:             &springBoot.Replicas,
 			RevisionHistoryLimit: &revisionHistoryLimit,
 			Template: v1.PodTemplateSpec{
-				ObjectMeta: meta,
+				ObjectMeta: templateMeta,
 				Spec:       *podSpec,
 			},
 			Strategy: appsv1.DeploymentStrategy{
 				Type:          "RollingUpdate",
 				RollingUpdate: &appsv1.RollingUpdateDeployment{},
 			},
-			Selector: selector,
-		}
-		return nil
-	}); err != nil {
+			Selector: &metav1.LabelSelector{
+				MatchLabels: labels,
+			},
+		},
+	}
+	var released []fieldPath
+	if springBoot.Autoscaling.Enabled {
+		// the horizontal pod autoscaler owns the replicas, they are released rather than removed
+		// so they are not reset to 1
+		deploy.Spec.Replicas = nil
+		released = append(released, replicasField)
+	}
+	if op, err := r.applyOwned(ctx, app, deploy, released...); err != nil {
 		log.Error(err, "Deployment reconcile failed")
-		return ctrl.Result{}, nil
+		r.Recorder.Event(app, v1.EventTypeWarning, "DeploymentFailed", err.Error())
+		r.reportStatus(ctx, log, app, service, deploy, err)
+		return ctrl.Result{}, err
 	} else {
 		log.Info(string(op) + " " + name + " deployment ")
+		r.recordOperation(app, "Deployment", op)
+	}
+
+	if err := r.reconcileAutoscaler(ctx, log, app, springBoot, meta); err != nil {
+		log.Error(err, "HorizontalPodAutoscaler reconcile failed")
+		r.Recorder.Event(app, v1.EventTypeWarning, "HorizontalPodAutoscalerFailed", err.Error())
+		r.reportStatus(ctx, log, app, service, deploy, err)
+		return ctrl.Result{}, err
+	}
+
+	if err := r.reconcilePodDisruptionBudget(ctx, log, app, springBoot, meta); err != nil {
+		log.Error(err, "PodDisruptionBudget reconcile failed")
+		r.Recorder.Event(app, v1.EventTypeWarning, "PodDisruptionBudgetFailed", err.Error())
+		r.reportStatus(ctx, log, app, service, deploy, err)
+		return ctrl.Result{}, err
+	}
+
+	if err := r.reconcileIngress(ctx, log, app, springBoot, meta); err != nil {
+		log.Error(err, "Ingress reconcile failed")
+		r.Recorder.Event(app, v1.EventTypeWarning, "IngressFailed", err.Error())
+		r.reportStatus(ctx, log, app, service, deploy, err)
+		return ctrl.Result{}, err
+	}
+
+	if previous := app.Status.Image; previous != "" && previous != springBoot.Image {
+		r.Recorder.Eventf(app, v1.EventTypeNormal, "ImageChanged", "Image changed from %s to %s", previous, springBoot.Image)
 	}
 
-	return ctrl.Result{}, nil
+	return ctrl.Result{}, r.reportStatus(ctx, log, app, service, deploy, nil)
+}
+
+// validateSpec validates the defaulted spec and parses its resource quantities.
+// The result is reported through the InvalidSpec condition and a Warning event
+func (r *SpringBootApplicationReconciler) validateSpec(app *springbootv1alpha1.SpringBootApplication,
+	springBoot *springbootv1alpha1.SpringBoot) (v1.ResourceRequirements, error) {
+	var resources v1.ResourceRequirements
+	fldPath := field.NewPath("spec", "springBoot")
+	errs := springBoot.Validate(fldPath)
+	errs = append(errs, springBoot.ValidateContainerNames(fldPath, app.Name)...)
+	errs = append(errs, springBoot.ValidateServicePorts(fldPath, app.Name)...)
+	if len(errs) == 0 {
+		var err error
+		if resources, err = springBoot.Resource.ResourceRequirements(); err != nil {
+			errs = append(errs, field.InternalError(field.NewPath("spec", "springBoot", "resource"), err))
+		}
+	}
+	if len(errs) > 0 {
+		err := errs.ToAggregate()
+		app.Status.SetCondition(springbootv1alpha1.Condition{
+			Type:               springbootv1alpha1.ConditionInvalidSpec,
+			Status:             v1.ConditionTrue,
+			ObservedGeneration: app.GetGeneration(),
+			Reason:             "ValidationFailed",
+			Message:            err.Error(),
+		})
+		r.Recorder.Event(app, v1.EventTypeWarning, "InvalidSpec", err.Error())
+		return resources, err
+	}
+	app.Status.SetCondition(springbootv1alpha1.Condition{
+		Type:               springbootv1alpha1.ConditionInvalidSpec,
+		Status:             v1.ConditionFalse,
+		ObservedGeneration: app.GetGeneration(),
+		Reason:             "ValidationSucceeded",
+	})
+	return resources, nil
+}
+
+// ownedObject is an object generated for an application
+type ownedObject interface {
+	runtime.Object
+	metav1.Object
+}
+
+// deleteOwned deletes obj if it exists and is controlled by the application,
+// objects created by somebody else are left alone
+func (r *SpringBootApplicationReconciler) deleteOwned(ctx context.Context, app *springbootv1alpha1.SpringBootApplication,
+	obj ownedObject) (bool, error) {
+	key := client.ObjectKey{Namespace: obj.GetNamespace(), Name: obj.GetName()}
+	if err := r.Get(ctx, key, obj); err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	if !metav1.IsControlledBy(obj, app) {
+		return false, nil
+	}
+	if err := r.Delete(ctx, obj); err != nil && !apierrors.IsNotFound(err) {
+		return false, err
+	}
+	return true, nil
+}
+
+// recordOperation emits a Normal event for the result of the apply of a generated object
+func (r *SpringBootApplicationReconciler) recordOperation(app *springbootv1alpha1.SpringBootApplication, kind string,
+	op controllerutil.OperationResult) {
+	switch op {
+	case controllerutil.OperationResultCreated:
+		r.Recorder.Eventf(app, v1.EventTypeNormal, kind+"Created", "%s %s created", kind, app.Name)
+	case controllerutil.OperationResultUpdated:
+		r.Recorder.Eventf(app, v1.EventTypeNormal, kind+"Updated", "%s %s updated", kind, app.Name)
+	default:
+		r.Recorder.Eventf(app, v1.EventTypeNormal, kind+"Unchanged", "%s %s is up to date", kind, app.Name)
+	}
+}
+
+// reportStatus writes the application status, a failed update is logged and returned
+// so that a conflict with a concurrent writer is retried
+func (r *SpringBootApplicationReconciler) reportStatus(ctx context.Context, log logr.Logger, app *springbootv1alpha1.SpringBootApplication,
+	service *v1.Service, deploy *appsv1.Deployment, reconcileErr error) error {
+	if err := r.updateStatus(ctx, app, service, deploy, reconcileErr); err != nil {
+		log.Error(err, "Status update failed")
+		return err
+	}
+	return nil
+}
+
+// referencingApplications maps an object to the applications referencing it by name through the index field
+func (r *SpringBootApplicationReconciler) referencingApplications(indexField string) handler.ToRequestsFunc {
+	return func(a handler.MapObject) []reconcile.Request {
+		apps := &springbootv1alpha1.SpringBootApplicationList{}
+		if err := r.List(context.Background(), apps, client.InNamespace(a.Meta.GetNamespace()),
+			client.MatchingField(indexField, a.Meta.GetName())); err != nil {
+			r.Log.Error(err, "List referencing applications failed", "field", indexField, "name", a.Meta.GetName())
+			return nil
+		}
+		requests := make([]reconcile.Request, 0, len(apps.Items))
+		for _, app := range apps.Items {
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{Namespace: app.Namespace, Name: app.Name},
+			})
+		}
+		return requests
+	}
 }
 
 func (r *SpringBootApplicationReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
+	if err := mgr.GetFieldIndexer().IndexField(&springbootv1alpha1.SpringBootApplication{}, configMapIndexField, indexConfigMapName); err != nil {
+		return err
+	}
+	if err := mgr.GetFieldIndexer().IndexField(&springbootv1alpha1.SpringBootApplication{}, secretIndexField, indexSecretNames); err != nil {
+		return err
+	}
+	if err := mgr.GetFieldIndexer().IndexField(&springbootv1alpha1.SpringBootApplication{}, profileIndexField, indexProfileRef); err != nil {
+		return err
+	}
+	builder := ctrl.NewControllerManagedBy(mgr).
 		For(&springbootv1alpha1.SpringBootApplication{}).
-		Complete(r)
+		Owns(&appsv1.Deployment{}).
+		Owns(&v1.Service{}).
+		Owns(&autoscalingv2beta2.HorizontalPodAutoscaler{}).
+		Owns(&policyv1beta1.PodDisruptionBudget{}).
+		Owns(&networkingv1beta1.Ingress{}).
+		Owns(&v1.ConfigMap{}).
+		Owns(&v1.ServiceAccount{}).
+		Watches(&source.Kind{Type: &v1.ConfigMap{}}, &handler.EnqueueRequestsFromMapFunc{
+			ToRequests: handler.ToRequestsFunc(r.configMapApplications),
+		}).
+		Watches(&source.Kind{Type: &v1.Secret{}}, &handler.EnqueueRequestsFromMapFunc{
+			ToRequests: handler.ToRequestsFunc(r.secretApplications),
+		}).
+		Watches(&source.Kind{Type: &springbootv1alpha1.SpringBootDefaults{}}, &handler.EnqueueRequestsFromMapFunc{
+			ToRequests: handler.ToRequestsFunc(r.namespaceApplications),
+		}).
+		Watches(&source.Kind{Type: &springbootv1alpha1.SpringBootProfile{}}, &handler.EnqueueRequestsFromMapFunc{
+			ToRequests: r.referencingApplications(profileIndexField),
+		}).
+		WithEventFilter(ignoreStatusChanges)
+	if r.ConfigChanges != nil {
+		builder = builder.Watches(&source.Channel{Source: r.ConfigChanges}, &handler.EnqueueRequestForObject{})
+	}
+	return builder.Complete(r)
 }
@@ -0,0 +1,180 @@
+/*
+Copyright 2020 qingmu.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	springbootv1alpha1 "spring-boot-operator/api/v1alpha1"
+	"spring-boot-operator/global"
+)
+
+// conflictingClient fails the next Deployment applies with a conflict, like a concurrent writer would
+type conflictingClient struct {
+	client.Client
+	conflicts int32
+	returned  int32
+}
+
+func (c *conflictingClient) Patch(ctx context.Context, obj runtime.Object, patch client.Patch, opts ...client.PatchOption) error {
+	if deploy, ok := obj.(*appsv1.Deployment); ok && atomic.AddInt32(&c.conflicts, -1) >= 0 {
+		atomic.AddInt32(&c.returned, 1)
+		return apierrors.NewConflict(appsv1.Resource("deployments"), deploy.Name, nil)
+	}
+	return c.Client.Patch(ctx, obj, patch, opts...)
+}
+
+var _ = Describe("SpringBootApplication controller", func() {
+	var ctx context.Context
+
+	BeforeEach(func() {
+		requireEnvtest()
+		ctx = context.Background()
+		config := global.GetGlobalConfig()
+		config.ImageRepository = "registry.example.com"
+		config.RequestCpu = "50m"
+		config.RequestMemory = "512Mi"
+		config.LimitMemory = "512Mi"
+		config.LivenessPath = "/actuator/health"
+		config.ReadinessPath = "/actuator/health"
+		config.ShutdownPath = "/spring/shutdown"
+		config.HostLogPath = "/var/applog"
+		config.Replicas = 1
+		config.Port = 8080
+	})
+
+	It("ignores applications that are already deleted", func() {
+		reconciler := &SpringBootApplicationReconciler{
+			Client:   k8sClient,
+			Log:      ctrl.Log.WithName("controllers").WithName("SpringBootApplication"),
+			Scheme:   scheme.Scheme,
+			Recorder: record.NewFakeRecorder(100),
+		}
+		result, err := reconciler.Reconcile(ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "missing"}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result).To(Equal(ctrl.Result{}))
+	})
+
+	It("keeps the image in the status when the profile can not be resolved", func() {
+		reconciler := &SpringBootApplicationReconciler{
+			Client:   k8sClient,
+			Log:      ctrl.Log.WithName("controllers").WithName("SpringBootApplication"),
+			Scheme:   scheme.Scheme,
+			Recorder: record.NewFakeRecorder(100),
+		}
+		key := types.NamespacedName{Namespace: "default", Name: "missing-profile"}
+		app := &springbootv1alpha1.SpringBootApplication{
+			ObjectMeta: metav1.ObjectMeta{Namespace: key.Namespace, Name: key.Name},
+			Spec: springbootv1alpha1.SpringBootApplicationSpec{
+				SpringBoot: springbootv1alpha1.SpringBoot{Version: "v1.0.0"},
+			},
+		}
+		Expect(k8sClient.Create(ctx, app)).To(Succeed())
+		_, err := reconciler.Reconcile(ctrl.Request{NamespacedName: key})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(k8sClient.Get(ctx, key, app)).To(Succeed())
+		Expect(app.Status.Image).To(Equal("registry.example.com/missing-profile:v1.0.0"))
+
+		app.Spec.ProfileRef = "missing"
+		Expect(k8sClient.Update(ctx, app)).To(Succeed())
+		_, err = reconciler.Reconcile(ctrl.Request{NamespacedName: key})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(k8sClient.Get(ctx, key, app)).To(Succeed())
+		Expect(app.Status.Image).To(Equal("registry.example.com/missing-profile:v1.0.0"))
+		condition := app.Status.GetCondition(springbootv1alpha1.ConditionReconcileError)
+		Expect(condition).NotTo(BeNil())
+		Expect(string(condition.Status)).To(Equal("True"))
+	})
+
+	It("retries a conflicting apply with the rate limited requeue", func() {
+		mgr, err := ctrl.NewManager(cfg, ctrl.Options{Scheme: scheme.Scheme, MetricsBindAddress: "0"})
+		Expect(err).NotTo(HaveOccurred())
+		conflicting := &conflictingClient{Client: mgr.GetClient()}
+		Expect((&SpringBootApplicationReconciler{
+			Client:   conflicting,
+			Log:      ctrl.Log.WithName("controllers").WithName("SpringBootApplication"),
+			Scheme:   mgr.GetScheme(),
+			Recorder: mgr.GetEventRecorderFor("spring-boot-operator"),
+		}).SetupWithManager(mgr)).To(Succeed())
+		stop := make(chan struct{})
+		defer close(stop)
+		go func() {
+			defer GinkgoRecover()
+			Expect(mgr.Start(stop)).To(Succeed())
+		}()
+
+		key := types.NamespacedName{Namespace: "default", Name: "conflict-demo"}
+		app := &springbootv1alpha1.SpringBootApplication{
+			ObjectMeta: metav1.ObjectMeta{Namespace: key.Namespace, Name: key.Name},
+			Spec: springbootv1alpha1.SpringBootApplicationSpec{
+				SpringBoot: springbootv1alpha1.SpringBoot{Version: "v1.0.0", Replicas: 1},
+			},
+		}
+		Expect(k8sClient.Create(ctx, app)).To(Succeed())
+
+		By("creating the deployment")
+		deploy := &appsv1.Deployment{}
+		Eventually(func() error {
+			return k8sClient.Get(ctx, key, deploy)
+		}, 10*time.Second).Should(Succeed())
+
+		By("changing the replicas while another writer updates the deployment")
+		atomic.StoreInt32(&conflicting.conflicts, 1)
+		Eventually(func() error {
+			// the controller writes the status concurrently
+			if err := k8sClient.Get(ctx, key, app); err != nil {
+				return err
+			}
+			app.Spec.SpringBoot.Replicas = 2
+			return k8sClient.Update(ctx, app)
+		}, 10*time.Second).Should(Succeed())
+
+		By("retrying the request until the apply succeeds")
+		Eventually(func() int32 {
+			if err := k8sClient.Get(ctx, key, deploy); err != nil || deploy.Spec.Replicas == nil {
+				return 0
+			}
+			return *deploy.Spec.Replicas
+		}, 10*time.Second).Should(Equal(int32(2)))
+		Expect(atomic.LoadInt32(&conflicting.returned)).To(Equal(int32(1)))
+
+		Eventually(func() string {
+			if err := k8sClient.Get(ctx, key, app); err != nil {
+				return ""
+			}
+			condition := app.Status.GetCondition(springbootv1alpha1.ConditionReconcileError)
+			if condition == nil {
+				return ""
+			}
+			return string(condition.Status)
+		}, 10*time.Second).Should(Equal("False"))
+	})
+})
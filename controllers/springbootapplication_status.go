@@ -0,0 +1,160 @@
+/*
+Copyright 2020 qingmu.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+
+	springbootv1alpha1 "spring-boot-operator/api/v1alpha1"
+)
+
+// updateStatus mirrors the state of the generated deployment and service into the
+// application status. reconcileErr is reported through the ReconcileError condition
+func (r *SpringBootApplicationReconciler) updateStatus(ctx context.Context, app *springbootv1alpha1.SpringBootApplication,
+	service *v1.Service, deploy *appsv1.Deployment, reconcileErr error) error {
+	status := &app.Status
+	generation := app.GetGeneration()
+	status.ObservedGeneration = generation
+	// the image of an unresolved profile based spec is unknown, the last resolved one is kept
+	var unresolved *profileError
+	if !errors.As(reconcileErr, &unresolved) {
+		status.Image = app.Spec.SpringBoot.Image
+	}
+
+	if service != nil {
+		status.ClusterIp = service.Spec.ClusterIP
+	}
+
+	if reconcileErr != nil {
+		status.SetCondition(springbootv1alpha1.Condition{
+			Type:               springbootv1alpha1.ConditionReconcileError,
+			Status:             v1.ConditionTrue,
+			ObservedGeneration: generation,
+			Reason:             "ReconcileFailed",
+			Message:            reconcileErr.Error(),
+		})
+	} else {
+		status.SetCondition(springbootv1alpha1.Condition{
+			Type:               springbootv1alpha1.ConditionReconcileError,
+			Status:             v1.ConditionFalse,
+			ObservedGeneration: generation,
+			Reason:             "ReconcileSucceeded",
+		})
+	}
+
+	if deploy != nil && !deploy.CreationTimestamp.IsZero() {
+		if deploy.Spec.Replicas != nil {
+			status.Replicas = *deploy.Spec.Replicas
+		}
+		status.ReadyReplicas = deploy.Status.ReadyReplicas
+		status.UpdatedReplicas = deploy.Status.UpdatedReplicas
+		status.AvailableReplicas = deploy.Status.AvailableReplicas
+		for _, condition := range deploymentConditions(deploy, status.Replicas) {
+			condition.ObservedGeneration = generation
+			r.recordRollout(app, condition)
+			status.SetCondition(condition)
+		}
+	}
+
+	return r.Status().Update(ctx, app)
+}
+
+// recordRollout emits an event when the rollout completes or fails, i.e. on the transition of the condition
+func (r *SpringBootApplicationReconciler) recordRollout(app *springbootv1alpha1.SpringBootApplication, condition springbootv1alpha1.Condition) {
+	previous := app.Status.GetCondition(condition.Type)
+	if previous != nil && previous.Status == condition.Status {
+		return
+	}
+	switch {
+	case condition.Type == springbootv1alpha1.ConditionProgressing && condition.Reason == "RolloutComplete" && previous != nil:
+		r.Recorder.Event(app, v1.EventTypeNormal, "RolloutComplete", condition.Message)
+	case condition.Type == springbootv1alpha1.ConditionDegraded && condition.Status == v1.ConditionTrue:
+		r.Recorder.Event(app, v1.EventTypeWarning, "RolloutFailed", condition.Message)
+	}
+}
+
+// deploymentConditions derives the Available, Progressing and Degraded conditions from the deployment
+func deploymentConditions(deploy *appsv1.Deployment, desired int32) []springbootv1alpha1.Condition {
+	available := springbootv1alpha1.Condition{
+		Type:    springbootv1alpha1.ConditionAvailable,
+		Status:  v1.ConditionFalse,
+		Reason:  "DeploymentPending",
+		Message: "The deployment has not reported availability yet",
+	}
+	if c := getDeploymentCondition(deploy, appsv1.DeploymentAvailable); c != nil {
+		available.Status = c.Status
+		available.Reason = c.Reason
+		available.Message = c.Message
+	}
+
+	progressing := springbootv1alpha1.Condition{
+		Type:    springbootv1alpha1.ConditionProgressing,
+		Status:  v1.ConditionFalse,
+		Reason:  "RolloutComplete",
+		Message: fmt.Sprintf("%d of %d replicas are updated and available", deploy.Status.AvailableReplicas, desired),
+	}
+	if !rolloutComplete(deploy, desired) {
+		progressing.Status = v1.ConditionTrue
+		progressing.Reason = "RollingOut"
+		progressing.Message = fmt.Sprintf("%d of %d replicas are updated, %d are ready",
+			deploy.Status.UpdatedReplicas, desired, deploy.Status.ReadyReplicas)
+	}
+
+	degraded := springbootv1alpha1.Condition{
+		Type:   springbootv1alpha1.ConditionDegraded,
+		Status: v1.ConditionFalse,
+		Reason: "AsExpected",
+	}
+	if c := getDeploymentCondition(deploy, appsv1.DeploymentProgressing); c != nil &&
+		c.Status == v1.ConditionFalse && c.Reason == "ProgressDeadlineExceeded" {
+		progressing.Status = v1.ConditionFalse
+		progressing.Reason = c.Reason
+		progressing.Message = c.Message
+		degraded.Status = v1.ConditionTrue
+		degraded.Reason = c.Reason
+		degraded.Message = c.Message
+	}
+	if c := getDeploymentCondition(deploy, appsv1.DeploymentReplicaFailure); c != nil && c.Status == v1.ConditionTrue {
+		degraded.Status = v1.ConditionTrue
+		degraded.Reason = c.Reason
+		degraded.Message = c.Message
+	}
+
+	return []springbootv1alpha1.Condition{available, progressing, degraded}
+}
+
+// rolloutComplete reports whether every desired replica runs the latest pod template
+func rolloutComplete(deploy *appsv1.Deployment, desired int32) bool {
+	return deploy.Status.ObservedGeneration >= deploy.Generation &&
+		deploy.Status.UpdatedReplicas == desired &&
+		deploy.Status.AvailableReplicas == desired &&
+		deploy.Status.Replicas == desired
+}
+
+func getDeploymentCondition(deploy *appsv1.Deployment, conditionType appsv1.DeploymentConditionType) *appsv1.DeploymentCondition {
+	for i := range deploy.Status.Conditions {
+		if deploy.Status.Conditions[i].Type == conditionType {
+			return &deploy.Status.Conditions[i]
+		}
+	}
+	return nil
+}
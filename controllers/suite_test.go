@@ -16,66 +16,93 @@ limitations under the License.
 
 package controllers
 
-//import (
-//	"path/filepath"
-//	"testing"
-//
-//	. "github.com/onsi/ginkgo"
-//	. "github.com/onsi/gomega"
-//	"k8s.io/client-go/kubernetes/scheme"
-//	"k8s.io/client-go/rest"
-//	"sigs.k8s.io/controller-runtime/pkg/client"
-//	"sigs.k8s.io/controller-runtime/pkg/envtest"
-//	"sigs.k8s.io/controller-runtime/pkg/envtest/printer"
-//	logf "sigs.k8s.io/controller-runtime/pkg/log"
-//	"sigs.k8s.io/controller-runtime/pkg/log/zap"
-//
-//	springbootv1alpha1 "spring-boot-operator/api/v1alpha1"
-//	// +kubebuilder:scaffold:imports
-//)
-//
-//// These tests use Ginkgo (BDD-style Go testing framework). Refer to
-//// http://onsi.github.io/ginkgo/ to learn more about Ginkgo.
-//
-//var cfg *rest.Config
-//var k8sClient client.Client
-//var testEnv *envtest.Environment
-//
-//func TestAPIs(t *testing.T) {
-//	RegisterFailHandler(Fail)
-//
-//	RunSpecsWithDefaultAndCustomReporters(t,
-//		"Controller Suite",
-//		[]Reporter{printer.NewlineReporter{}})
-//}
-//
-//var _ = BeforeSuite(func(done Done) {
-//	logf.SetLogger(zap.LoggerTo(GinkgoWriter, true))
-//
-//	By("bootstrapping test environment")
-//	testEnv = &envtest.Environment{
-//		CRDDirectoryPaths: []string{filepath.Join("..", "config", "crd", "bases")},
-//	}
-//
-//	var err error
-//	cfg, err = testEnv.Start()
-//	Expect(err).ToNot(HaveOccurred())
-//	Expect(cfg).ToNot(BeNil())
-//
-//	err = springbootv1alpha1.AddToScheme(scheme.Scheme)
-//	Expect(err).NotTo(HaveOccurred())
-//
-//	// +kubebuilder:scaffold:scheme
-//
-//	k8sClient, err = client.New(cfg, client.Options{Scheme: scheme.Scheme})
-//	Expect(err).ToNot(HaveOccurred())
-//	Expect(k8sClient).ToNot(BeNil())
-//
-//	close(done)
-//}, 60)
-//
-//var _ = AfterSuite(func() {
-//	By("tearing down the test environment")
-//	err := testEnv.Stop()
-//	Expect(err).ToNot(HaveOccurred())
-//})
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+	"sigs.k8s.io/controller-runtime/pkg/envtest/printer"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	springbootv1alpha1 "spring-boot-operator/api/v1alpha1"
+	// +kubebuilder:scaffold:imports
+)
+
+// These tests use Ginkgo (BDD-style Go testing framework). Refer to
+// http://onsi.github.io/ginkgo/ to learn more about Ginkgo.
+
+var cfg *rest.Config
+var k8sClient client.Client
+var testEnv *envtest.Environment
+
+func TestAPIs(t *testing.T) {
+	RegisterFailHandler(Fail)
+
+	RunSpecsWithDefaultAndCustomReporters(t,
+		"Controller Suite",
+		[]Reporter{printer.NewlineReporter{}})
+}
+
+// envtestAvailable reports whether the etcd and kube-apiserver binaries used by envtest are installed.
+// The specs run against a real api server only, they are skipped without one
+func envtestAvailable() bool {
+	assets := os.Getenv("KUBEBUILDER_ASSETS")
+	if assets == "" {
+		assets = "/usr/local/kubebuilder/bin"
+	}
+	_, err := os.Stat(filepath.Join(assets, "kube-apiserver"))
+	return err == nil
+}
+
+var _ = BeforeSuite(func(done Done) {
+	logf.SetLogger(zap.LoggerTo(GinkgoWriter, true))
+
+	err := springbootv1alpha1.AddToScheme(scheme.Scheme)
+	Expect(err).NotTo(HaveOccurred())
+
+	// +kubebuilder:scaffold:scheme
+
+	if !envtestAvailable() {
+		By("envtest binaries not found, set KUBEBUILDER_ASSETS to run the specs")
+		close(done)
+		return
+	}
+
+	By("bootstrapping test environment")
+	testEnv = &envtest.Environment{
+		CRDDirectoryPaths: []string{filepath.Join("..", "config", "crd", "bases")},
+	}
+
+	cfg, err = testEnv.Start()
+	Expect(err).ToNot(HaveOccurred())
+	Expect(cfg).ToNot(BeNil())
+
+	k8sClient, err = client.New(cfg, client.Options{Scheme: scheme.Scheme})
+	Expect(err).ToNot(HaveOccurred())
+	Expect(k8sClient).ToNot(BeNil())
+
+	close(done)
+}, 60)
+
+var _ = AfterSuite(func() {
+	if testEnv == nil {
+		return
+	}
+	By("tearing down the test environment")
+	err := testEnv.Stop()
+	Expect(err).ToNot(HaveOccurred())
+})
+
+// requireEnvtest skips the spec when the test environment is not running
+func requireEnvtest() {
+	if testEnv == nil {
+		Skip("envtest binaries not found")
+	}
+}
@@ -0,0 +1,50 @@
+/*
+Copyright 2020 qingmu.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	v1 "k8s.io/api/core/v1"
+
+	springbootv1alpha1 "spring-boot-operator/api/v1alpha1"
+)
+
+const logVolumeName = "applogpath"
+
+// applyVolumes adds the log volume and the volumes of the spec to the pod
+func applyVolumes(podSpec *v1.PodSpec, container *v1.Container, springBoot *springbootv1alpha1.SpringBoot) {
+	hostLog := springBoot.Path.HostLog
+	if hostLog != "" && springBoot.LogVolume != springbootv1alpha1.LogVolumeNone {
+		source := v1.VolumeSource{EmptyDir: &v1.EmptyDirVolumeSource{}}
+		if springBoot.LogVolume != springbootv1alpha1.LogVolumeEmptyDir {
+			hostPathType := v1.HostPathDirectoryOrCreate
+			source = v1.VolumeSource{
+				HostPath: &v1.HostPathVolumeSource{
+					Path: hostLog,
+					Type: &hostPathType,
+				},
+			}
+		}
+		podSpec.Volumes = append(podSpec.Volumes, v1.Volume{Name: logVolumeName, VolumeSource: source})
+		container.VolumeMounts = append(container.VolumeMounts, v1.VolumeMount{
+			Name:      logVolumeName,
+			MountPath: hostLog,
+		})
+	}
+
+	podSpec.Volumes = append(podSpec.Volumes, springBoot.Volumes...)
+	container.VolumeMounts = append(container.VolumeMounts, springBoot.VolumeMounts...)
+}
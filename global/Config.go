@@ -1,36 +1,65 @@
 package global
 
-import "sync"
+import (
+	"sync"
+	"sync/atomic"
 
-var c *configureSpec
+	v1 "k8s.io/api/core/v1"
+)
+
+var c atomic.Value
 
 var once sync.Once
 
+// GetGlobalConfig returns the current configuration. It is replaced, never modified,
+// when the configuration is reloaded
 func GetGlobalConfig() *configureSpec {
 	once.Do(func() {
-		c = &configureSpec{}
+		c.Store(&configureSpec{})
 	})
-	return c
+	return c.Load().(*configureSpec)
+}
+
+// setGlobalConfig replaces the current configuration
+func setGlobalConfig(config *configureSpec) {
+	once.Do(func() {})
+	c.Store(config)
 }
 
 type configureSpec struct {
-	ImageRepository  string
-	RequestCpu       string
-	LimitCpu         string
-	RequestMemory    string
-	LimitMemory      string
-	LivenessPath     string
-	ReadinessPath    string
-	HostLogPath      string
-	ShutdownPath     string
-	Replicas         int32
-	Port             int32
-	Env              map[string]string
-	ImagePullSecrets []string
+	ImageRepository  string   `json:"imageRepository,omitempty"`
+	RequestCpu       string   `json:"requestCpu,omitempty"`
+	LimitCpu         string   `json:"limitCpu,omitempty"`
+	RequestMemory    string   `json:"requestMemory,omitempty"`
+	LimitMemory      string   `json:"limitMemory,omitempty"`
+	LivenessPath     string   `json:"livenessPath,omitempty"`
+	ReadinessPath    string   `json:"readinessPath,omitempty"`
+	HostLogPath      string   `json:"hostLogPath,omitempty"`
+	ShutdownPath     string   `json:"shutdownPath,omitempty"`
+	Replicas         int32    `json:"replicas,omitempty"`
+	Port             int32    `json:"port,omitempty"`
+	Env              EnvVars  `json:"env,omitempty"`
+	ImagePullSecrets []string `json:"imagePullSecrets,omitempty"`
 	// failure-domain.beta.kubernetes.io/zone
-	NodeAffinityKey string
+	NodeAffinityKey string `json:"nodeAffinityKey,omitempty"`
 	// "cn-g", "cn-h", "cn-i"
-	NodeAffinityValues []string
+	NodeAffinityValues []string `json:"nodeAffinityValues,omitempty"`
 	// In
-	NodeAffinityOperator string
+	NodeAffinityOperator string `json:"nodeAffinityOperator,omitempty"`
+	// The default pod security settings, unset when nil or empty
+	RunAsNonRoot                 *bool  `json:"runAsNonRoot,omitempty"`
+	RunAsUser                    *int64 `json:"runAsUser,omitempty"`
+	FSGroup                      *int64 `json:"fsGroup,omitempty"`
+	ReadOnlyRootFilesystem       *bool  `json:"readOnlyRootFilesystem,omitempty"`
+	AutomountServiceAccountToken *bool  `json:"automountServiceAccountToken,omitempty"`
+	// ALL
+	DropCapabilities []string `json:"dropCapabilities,omitempty"`
+	// runtime/default
+	SeccompProfile string `json:"seccompProfile,omitempty"`
+	// The default scheduling of the pods, unset when empty
+	Tolerations               []v1.Toleration               `json:"tolerations,omitempty"`
+	NodeSelector              map[string]string             `json:"nodeSelector,omitempty"`
+	TopologySpreadConstraints []v1.TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
+	// Soft, Hard or Off
+	PodAntiAffinity string `json:"podAntiAffinity,omitempty"`
 }
@@ -0,0 +1,50 @@
+package global
+
+import (
+	"encoding/json"
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// EnvVars is the env added to every application, sorted by name. It is a list of the
+// kubernetes env, so valueFrom is supported, or a map of names to values
+type EnvVars []v1.EnvVar
+
+// UnmarshalJSON accepts the list and the map form, e.g. {"TZ": "Asia/Shanghai"}
+func (e *EnvVars) UnmarshalJSON(data []byte) error {
+	var values map[string]string
+	if err := json.Unmarshal(data, &values); err == nil {
+		env := make(EnvVars, 0, len(values))
+		for name, value := range values {
+			env = append(env, v1.EnvVar{Name: name, Value: value})
+		}
+		*e = env.sorted()
+		return nil
+	}
+	var env []v1.EnvVar
+	if err := json.Unmarshal(data, &env); err != nil {
+		return err
+	}
+	*e = EnvVars(env).sorted()
+	return nil
+}
+
+// sorted returns the env sorted by name, the last one wins among the same name
+func (e EnvVars) sorted() EnvVars {
+	if e == nil {
+		return nil
+	}
+	index := make(map[string]int, len(e))
+	env := make(EnvVars, 0, len(e))
+	for _, v := range e {
+		if i, ok := index[v.Name]; ok {
+			env[i] = v
+			continue
+		}
+		index[v.Name] = len(env)
+		env = append(env, v)
+	}
+	sort.SliceStable(env, func(i, j int) bool { return env[i].Name < env[j].Name })
+	return env
+}
@@ -0,0 +1,94 @@
+package global
+
+import (
+	"reflect"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+)
+
+func TestEnvVarsUnmarshal(t *testing.T) {
+	tests := []struct {
+		name    string
+		yaml    string
+		want    EnvVars
+		wantErr bool
+	}{
+		{
+			name: "map, sorted",
+			yaml: "env:\n  B: b\n  A: a\n",
+			want: EnvVars{{Name: "A", Value: "a"}, {Name: "B", Value: "b"}},
+		},
+		{
+			name: "list, sorted and de-duplicated",
+			yaml: "env:\n- name: B\n  value: b\n- name: A\n  value: '1'\n- name: A\n  value: '2'\n",
+			want: EnvVars{{Name: "A", Value: "2"}, {Name: "B", Value: "b"}},
+		},
+		{
+			name: "list with value from",
+			yaml: "env:\n- name: POD_IP\n  valueFrom:\n    fieldRef:\n      fieldPath: status.podIP\n",
+			want: EnvVars{{Name: "POD_IP", ValueFrom: &v1.EnvVarSource{FieldRef: &v1.ObjectFieldSelector{FieldPath: "status.podIP"}}}},
+		},
+		{
+			name:    "invalid",
+			yaml:    "env: value\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &configureSpec{}
+			err := yaml.UnmarshalStrict([]byte(tt.yaml), config)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unmarshal error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(config.Env, tt.want) {
+				t.Errorf("env = %v, want %v", config.Env, tt.want)
+			}
+		})
+	}
+}
+
+func TestFromEnvEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		vars    map[string]string
+		want    EnvVars
+		wantErr bool
+	}{
+		{
+			name: "unset",
+			vars: map[string]string{},
+		},
+		{
+			name: "pairs, sorted",
+			vars: map[string]string{"SPRING_BOOT_ENV": "B=b;A=a=1;C"},
+			want: EnvVars{{Name: "A", Value: "a=1"}, {Name: "B", Value: "b"}, {Name: "C"}},
+		},
+		{
+			name: "value from overrides pairs",
+			vars: map[string]string{
+				"SPRING_BOOT_ENV":      "A=a;B=b",
+				"SPRING_BOOT_ENV_FROM": `[{"name":"A","valueFrom":{"fieldRef":{"fieldPath":"status.podIP"}}}]`,
+			},
+			want: EnvVars{{Name: "A", ValueFrom: &v1.EnvVarSource{FieldRef: &v1.ObjectFieldSelector{FieldPath: "status.podIP"}}}, {Name: "B", Value: "b"}},
+		},
+		{
+			name:    "invalid value from",
+			vars:    map[string]string{"SPRING_BOOT_ENV_FROM": "A=a"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := fromEnv(func(name string) string { return tt.vars[name] })
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("fromEnv() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(config.Env, tt.want) {
+				t.Errorf("env = %v, want %v", config.Env, tt.want)
+			}
+		})
+	}
+}
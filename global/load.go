@@ -0,0 +1,303 @@
+package global
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"sigs.k8s.io/yaml"
+)
+
+// ConfigMapKey is the key of the operator ConfigMap holding the yaml configuration
+const ConfigMapKey = "config.yaml"
+
+var (
+	envConfig     *configureSpec
+	envConfigLock sync.Mutex
+)
+
+// LoadEnv reads the configuration from the environment and makes it the current one.
+// It is the fallback of every value not set in the ConfigMap
+func LoadEnv() (*configureSpec, error) {
+	config, err := fromEnv(os.Getenv)
+	if err != nil {
+		return nil, err
+	}
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+	envConfigLock.Lock()
+	defer envConfigLock.Unlock()
+	envConfig = config
+	setGlobalConfig(config)
+	return config, nil
+}
+
+// Load overrides the environment configuration with the yaml configuration of the ConfigMap,
+// nil data restores the environment configuration. A key set in the yaml replaces the environment
+// value as a whole, e.g. nodeSelector is not merged key by key, and null unsets it. An invalid
+// configuration is rejected and the current one is kept. It reports whether the current configuration changed
+func Load(data []byte) (bool, error) {
+	envConfigLock.Lock()
+	defer envConfigLock.Unlock()
+	config, err := overlay(envConfig, data)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s: %v", ConfigMapKey, err)
+	}
+	if err := config.validate(); err != nil {
+		return false, fmt.Errorf("invalid %s: %v", ConfigMapKey, err)
+	}
+	if reflect.DeepEqual(config, GetGlobalConfig()) {
+		return false, nil
+	}
+	setGlobalConfig(config)
+	return true, nil
+}
+
+// Validate checks a configuration in the format of the ConfigMap, e.g. the namespace defaults
+// mirroring the operator configuration
+func Validate(data []byte) error {
+	config := &configureSpec{}
+	if err := yaml.UnmarshalStrict(data, config); err != nil {
+		return err
+	}
+	return config.validate()
+}
+
+// overlay returns a copy of the environment configuration with the top level keys of the yaml replaced
+func overlay(envConfig *configureSpec, data []byte) (*configureSpec, error) {
+	fields := map[string]json.RawMessage{}
+	if envConfig != nil {
+		envJSON, err := json.Marshal(envConfig)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(envJSON, &fields); err != nil {
+			return nil, err
+		}
+	}
+	if data != nil {
+		// rejects unknown keys and values of the wrong type
+		if err := yaml.UnmarshalStrict(data, &configureSpec{}); err != nil {
+			return nil, err
+		}
+		dataJSON, err := yaml.YAMLToJSON(data)
+		if err != nil {
+			return nil, err
+		}
+		dataFields := map[string]json.RawMessage{}
+		if err := json.Unmarshal(dataJSON, &dataFields); err != nil {
+			return nil, err
+		}
+		for key, value := range dataFields {
+			fields[key] = value
+		}
+	}
+	configJSON, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+	config := &configureSpec{}
+	if err := json.Unmarshal(configJSON, config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// fromEnv reads the configuration from the environment, the defaults apply to unset variables
+func fromEnv(getenv func(string) string) (*configureSpec, error) {
+	config := &configureSpec{
+		ImageRepository:      getenv("IMAGE_REPOSITORY"),
+		RequestCpu:           envOrDefault(getenv, "REQUEST_CPU", "50m"),
+		LimitCpu:             getenv("LIMIT_CPU"),
+		RequestMemory:        envOrDefault(getenv, "REQUEST_MEMORY", "2Gi"),
+		LimitMemory:          envOrDefault(getenv, "LIMIT_MEMORY", "2Gi"),
+		ReadinessPath:        envOrDefault(getenv, "READINESS_PATH", "/actuator/health"),
+		LivenessPath:         envOrDefault(getenv, "LIVENESS_PATH", "/actuator/health"),
+		ShutdownPath:         envOrDefault(getenv, "SHUTDOWN_PATH", "/spring/shutdown"),
+		HostLogPath:          envOrDefault(getenv, "HOST_LOG_PATH", "/var/applog"),
+		ImagePullSecrets:     splitList(getenv("IMAGE_PULL_SECRETS")),
+		NodeAffinityKey:      getenv("NODE_AFFINITY_KEY"),
+		NodeAffinityValues:   splitList(getenv("NODE_AFFINITY_VALUES")),
+		NodeAffinityOperator: getenv("NODE_AFFINITY_OPERATOR"),
+		DropCapabilities:     splitList(getenv("DROP_CAPABILITIES")),
+		SeccompProfile:       getenv("SECCOMP_PROFILE"),
+		PodAntiAffinity:      getenv("POD_ANTI_AFFINITY"),
+	}
+
+	replicas, err := strconv.ParseInt(envOrDefault(getenv, "REPLICAS", "3"), 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("REPLICAS is not a number: %v", err)
+	}
+	config.Replicas = int32(replicas)
+
+	port, err := strconv.ParseInt(envOrDefault(getenv, "SPRING_BOOT_DEFAULT_PORT", "8080"), 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("SPRING_BOOT_DEFAULT_PORT is not a number: %v", err)
+	}
+	config.Port = int32(port)
+
+	// k=v;k1=v1, a value may contain '='
+	if env := getenv("SPRING_BOOT_ENV"); env != "" {
+		for _, kv := range strings.Split(env, ";") {
+			if kv == "" {
+				continue
+			}
+			kyarray := strings.SplitN(kv, "=", 2)
+			if len(kyarray) == 2 {
+				config.Env = append(config.Env, v1.EnvVar{Name: kyarray[0], Value: kyarray[1]})
+			} else {
+				config.Env = append(config.Env, v1.EnvVar{Name: kyarray[0]})
+			}
+		}
+		config.Env = config.Env.sorted()
+	}
+	// a json list of the kubernetes env, for values read from secrets, config maps or fields
+	if env := getenv("SPRING_BOOT_ENV_FROM"); env != "" {
+		var envFrom EnvVars
+		if err := json.Unmarshal([]byte(env), &envFrom); err != nil {
+			return nil, fmt.Errorf("SPRING_BOOT_ENV_FROM is not valid json: %v", err)
+		}
+		config.Env = append(config.Env, envFrom...).sorted()
+	}
+
+	// k=v,k1=v1
+	if nodeSelector := getenv("NODE_SELECTOR"); nodeSelector != "" {
+		config.NodeSelector = make(map[string]string)
+		for _, kv := range strings.Split(nodeSelector, ",") {
+			kyarray := strings.SplitN(kv, "=", 2)
+			if len(kyarray) != 2 {
+				return nil, fmt.Errorf("NODE_SELECTOR must be key=value pairs separated by ',', got %q", nodeSelector)
+			}
+			config.NodeSelector[kyarray[0]] = kyarray[1]
+		}
+	}
+
+	bools := []struct {
+		name  string
+		value **bool
+	}{
+		{"RUN_AS_NON_ROOT", &config.RunAsNonRoot},
+		{"READ_ONLY_ROOT_FILESYSTEM", &config.ReadOnlyRootFilesystem},
+		{"AUTOMOUNT_SERVICE_ACCOUNT_TOKEN", &config.AutomountServiceAccountToken},
+	}
+	for _, b := range bools {
+		if env := getenv(b.name); env != "" {
+			value, err := strconv.ParseBool(env)
+			if err != nil {
+				return nil, fmt.Errorf("%s is not a boolean: %v", b.name, err)
+			}
+			*b.value = &value
+		}
+	}
+	ints := []struct {
+		name  string
+		value **int64
+	}{
+		{"RUN_AS_USER", &config.RunAsUser},
+		{"FS_GROUP", &config.FSGroup},
+	}
+	for _, i := range ints {
+		if env := getenv(i.name); env != "" {
+			value, err := strconv.ParseInt(env, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("%s is not a number: %v", i.name, err)
+			}
+			*i.value = &value
+		}
+	}
+
+	// the tolerations and topology spread constraints are json lists of the kubernetes types
+	if env := getenv("TOLERATIONS"); env != "" {
+		if err := json.Unmarshal([]byte(env), &config.Tolerations); err != nil {
+			return nil, fmt.Errorf("TOLERATIONS is not valid json: %v", err)
+		}
+	}
+	if env := getenv("TOPOLOGY_SPREAD_CONSTRAINTS"); env != "" {
+		if err := json.Unmarshal([]byte(env), &config.TopologySpreadConstraints); err != nil {
+			return nil, fmt.Errorf("TOPOLOGY_SPREAD_CONSTRAINTS is not valid json: %v", err)
+		}
+	}
+	return config, nil
+}
+
+// validate rejects values that would make every application fail to reconcile
+func (c *configureSpec) validate() error {
+	quantities := []struct{ name, value string }{
+		{"requestCpu", c.RequestCpu},
+		{"limitCpu", c.LimitCpu},
+		{"requestMemory", c.RequestMemory},
+		{"limitMemory", c.LimitMemory},
+	}
+	for _, quantity := range quantities {
+		if quantity.value != "" {
+			if _, err := resource.ParseQuantity(quantity.value); err != nil {
+				return fmt.Errorf("%s %q: %v", quantity.name, quantity.value, err)
+			}
+		}
+	}
+	paths := []struct{ name, value string }{
+		{"livenessPath", c.LivenessPath},
+		{"readinessPath", c.ReadinessPath},
+		{"hostLogPath", c.HostLogPath},
+		{"shutdownPath", c.ShutdownPath},
+	}
+	for _, path := range paths {
+		if path.value != "" && !strings.HasPrefix(path.value, "/") {
+			return fmt.Errorf("%s %q must start with '/'", path.name, path.value)
+		}
+	}
+	if c.Replicas < 0 {
+		return fmt.Errorf("replicas %d must be greater than or equal to 0", c.Replicas)
+	}
+	if c.Port < 1 || c.Port > 65535 {
+		return fmt.Errorf("port %d must be between 1 and 65535", c.Port)
+	}
+	for _, env := range c.Env {
+		if env.Name == "" {
+			return fmt.Errorf("env name is required")
+		}
+		if env.Value != "" && env.ValueFrom != nil {
+			return fmt.Errorf("env %s may not have both value and valueFrom", env.Name)
+		}
+	}
+	if c.NodeAffinityKey != "" && c.NodeAffinityOperator == "" {
+		return fmt.Errorf("nodeAffinityOperator is required when nodeAffinityKey is set")
+	}
+	if c.RunAsUser != nil && *c.RunAsUser < 0 {
+		return fmt.Errorf("runAsUser %d must be greater than or equal to 0", *c.RunAsUser)
+	}
+	if c.FSGroup != nil && *c.FSGroup < 0 {
+		return fmt.Errorf("fsGroup %d must be greater than or equal to 0", *c.FSGroup)
+	}
+	switch c.PodAntiAffinity {
+	case "", "Soft", "Hard", "Off":
+	default:
+		return fmt.Errorf("podAntiAffinity %q must be one of Soft, Hard, Off", c.PodAntiAffinity)
+	}
+	return nil
+}
+
+func envOrDefault(getenv func(string) string, name, defaultValue string) string {
+	if value := getenv(name); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+// splitList splits a ',' separated list, nil when empty
+func splitList(value string) []string {
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(strings.Trim(strings.TrimSpace(item), `"`)); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
@@ -0,0 +1,169 @@
+package global
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFromEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		vars    map[string]string
+		check   func(config *configureSpec) bool
+		wantErr bool
+	}{
+		{
+			name: "defaults",
+			vars: map[string]string{},
+			check: func(c *configureSpec) bool {
+				return c.Replicas == 3 && c.Port == 8080 && c.HostLogPath == "/var/applog"
+			},
+		},
+		{
+			name: "node affinity values, quotes and spaces trimmed",
+			vars: map[string]string{"NODE_AFFINITY_VALUES": `"a", b ,,"c"`},
+			check: func(c *configureSpec) bool {
+				return reflect.DeepEqual(c.NodeAffinityValues, []string{"a", "b", "c"})
+			},
+		},
+		{
+			name:  "empty node affinity values",
+			vars:  map[string]string{"NODE_AFFINITY_VALUES": `""`},
+			check: func(c *configureSpec) bool { return c.NodeAffinityValues == nil },
+		},
+		{
+			name: "node selector",
+			vars: map[string]string{"NODE_SELECTOR": "zone=a,disk=ssd"},
+			check: func(c *configureSpec) bool {
+				return reflect.DeepEqual(c.NodeSelector, map[string]string{"zone": "a", "disk": "ssd"})
+			},
+		},
+		{
+			name: "security",
+			vars: map[string]string{"RUN_AS_NON_ROOT": "true", "RUN_AS_USER": "1000"},
+			check: func(c *configureSpec) bool {
+				return c.RunAsNonRoot != nil && *c.RunAsNonRoot && c.RunAsUser != nil && *c.RunAsUser == 1000
+			},
+		},
+		{name: "invalid node selector", vars: map[string]string{"NODE_SELECTOR": "zone"}, wantErr: true},
+		{name: "invalid replicas", vars: map[string]string{"REPLICAS": "three"}, wantErr: true},
+		{name: "invalid boolean", vars: map[string]string{"RUN_AS_NON_ROOT": "yes please"}, wantErr: true},
+		{name: "invalid tolerations", vars: map[string]string{"TOLERATIONS": "key=value"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := fromEnv(func(name string) string { return tt.vars[name] })
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("fromEnv() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !tt.check(config) {
+				t.Errorf("fromEnv() = %+v", config)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	negative := int64(-1)
+	tests := []struct {
+		name    string
+		config  configureSpec
+		wantErr bool
+	}{
+		{"valid", configureSpec{Port: 8080, RequestCpu: "50m", LivenessPath: "/health"}, false},
+		{"invalid quantity", configureSpec{Port: 8080, LimitMemory: "2 gigs"}, true},
+		{"relative path", configureSpec{Port: 8080, HostLogPath: "var/applog"}, true},
+		{"negative replicas", configureSpec{Port: 8080, Replicas: -1}, true},
+		{"port out of range", configureSpec{Port: 70000}, true},
+		{"env without name", configureSpec{Port: 8080, Env: EnvVars{{Value: "a"}}}, true},
+		{"node affinity without operator", configureSpec{Port: 8080, NodeAffinityKey: "zone"}, true},
+		{"negative run as user", configureSpec{Port: 8080, RunAsUser: &negative}, true},
+		{"unknown pod anti affinity", configureSpec{Port: 8080, PodAntiAffinity: "Sometimes"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.config.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	envConfigLock.Lock()
+	previous := envConfig
+	envConfig = &configureSpec{
+		ImageRepository: "env.example.com",
+		Port:            8080,
+		NodeSelector:    map[string]string{"zone": "a", "disk": "ssd"},
+	}
+	envConfigLock.Unlock()
+	defer func() {
+		envConfigLock.Lock()
+		envConfig = previous
+		envConfigLock.Unlock()
+		setGlobalConfig(&configureSpec{})
+	}()
+
+	tests := []struct {
+		name        string
+		data        []byte
+		want        *configureSpec
+		wantChanged bool
+		wantErr     bool
+	}{
+		{
+			name:        "environment",
+			want:        &configureSpec{ImageRepository: "env.example.com", Port: 8080, NodeSelector: map[string]string{"zone": "a", "disk": "ssd"}},
+			wantChanged: true,
+		},
+		{
+			name:        "fields replaced as a whole",
+			data:        []byte("imageRepository: yaml.example.com\nnodeSelector:\n  zone: b\n"),
+			want:        &configureSpec{ImageRepository: "yaml.example.com", Port: 8080, NodeSelector: map[string]string{"zone": "b"}},
+			wantChanged: true,
+		},
+		{
+			name:        "null unsets",
+			data:        []byte("nodeSelector: null\n"),
+			want:        &configureSpec{ImageRepository: "env.example.com", Port: 8080},
+			wantChanged: true,
+		},
+		{
+			name: "unchanged",
+			data: []byte("nodeSelector: null\n"),
+			want: &configureSpec{ImageRepository: "env.example.com", Port: 8080},
+		},
+		{
+			name:    "unknown key rejected, current kept",
+			data:    []byte("imageRepo: yaml.example.com\n"),
+			want:    &configureSpec{ImageRepository: "env.example.com", Port: 8080},
+			wantErr: true,
+		},
+		{
+			name:    "invalid value rejected, current kept",
+			data:    []byte("port: 0\n"),
+			want:    &configureSpec{ImageRepository: "env.example.com", Port: 8080},
+			wantErr: true,
+		},
+		{
+			name:        "reset",
+			want:        &configureSpec{ImageRepository: "env.example.com", Port: 8080, NodeSelector: map[string]string{"zone": "a", "disk": "ssd"}},
+			wantChanged: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changed, err := Load(tt.data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Load() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if changed != tt.wantChanged {
+				t.Errorf("Load() changed = %v, want %v", changed, tt.wantChanged)
+			}
+			if got := GetGlobalConfig(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("config = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -17,18 +17,26 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"flag"
+	"io/ioutil"
 	"os"
 	"spring-boot-operator/global"
-	"strconv"
 	"strings"
+	"time"
 
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
+	"k8s.io/client-go/rest"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
 	springbootv1alpha1 "spring-boot-operator/api/v1alpha1"
@@ -51,10 +59,21 @@ func init() {
 func main() {
 	var metricsAddr string
 	var enableLeaderElection bool
+	var syncPeriod time.Duration
+	var configMapName string
+	var legacyFieldManager string
 	flag.StringVar(&metricsAddr, "metrics-addr", ":8080", "The address the metric endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "enable-leader-election", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
+	flag.DurationVar(&syncPeriod, "sync-period", 10*time.Hour,
+		"The period after which every SpringBootApplication is reconciled again, even without changes.")
+	flag.StringVar(&configMapName, "config-map", "spring-boot-operator-config",
+		"The ConfigMap in the operator namespace holding the operator configuration under the key "+global.ConfigMapKey+". "+
+			"Values not set there are read from the environment.")
+	flag.StringVar(&legacyFieldManager, "legacy-field-manager", strings.Split(rest.DefaultKubernetesUserAgent(), "/")[0],
+		"The field manager of the objects generated by operator versions updating rather than applying them, "+
+			"by default the one derived from the user agent of the operator. Empty keeps their fields as they are.")
 	flag.Parse()
 
 	ctrl.SetLogger(zap.New(zap.UseDevMode(true)))
@@ -66,20 +85,54 @@ func main() {
 		Port:               9443,
 		LeaderElection:     enableLeaderElection,
 		LeaderElectionID:   "91a3bf26.qingmu.io",
+		SyncPeriod:         &syncPeriod,
 	})
 	if err != nil {
 		setupLog.Error(err, "unable to start manager")
 		os.Exit(1)
 	}
 
+	configMap := types.NamespacedName{Namespace: operatorNamespace(), Name: configMapName}
+	loadConfigMap(mgr.GetAPIReader(), configMap)
+
+	configChanges := make(chan event.GenericEvent)
 	if err = (&controllers.SpringBootApplicationReconciler{
-		Client: mgr.GetClient(),
-		Log:    ctrl.Log.WithName("controllers").WithName("SpringBootApplication"),
-		Scheme: mgr.GetScheme(),
+		Client:             mgr.GetClient(),
+		Log:                ctrl.Log.WithName("controllers").WithName("SpringBootApplication"),
+		Scheme:             mgr.GetScheme(),
+		Recorder:           mgr.GetEventRecorderFor("spring-boot-operator"),
+		ConfigChanges:      configChanges,
+		LegacyFieldManager: legacyFieldManager,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "SpringBootApplication")
 		os.Exit(1)
 	}
+	if err = (&controllers.GlobalConfigReconciler{
+		Client:        mgr.GetClient(),
+		Log:           ctrl.Log.WithName("controllers").WithName("GlobalConfig"),
+		Recorder:      mgr.GetEventRecorderFor("spring-boot-operator"),
+		ConfigMap:     configMap,
+		ConfigChanges: configChanges,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "GlobalConfig")
+		os.Exit(1)
+	}
+	if os.Getenv("ENABLE_WEBHOOKS") == "true" {
+		if err = (&springbootv1alpha1.SpringBootApplication{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "SpringBootApplication")
+			os.Exit(1)
+		}
+		if err = (&springbootv1alpha1.SpringBootDefaults{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "SpringBootDefaults")
+			os.Exit(1)
+		}
+		if err = (&springbootv1alpha1.SpringBootProfile{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "SpringBootProfile")
+			os.Exit(1)
+		}
+	} else {
+		setupLog.Info("Not set env ENABLE_WEBHOOKS=true, admission webhooks are disabled")
+	}
 	// +kubebuilder:scaffold:builder
 
 	setupLog.Info("starting manager")
@@ -89,168 +142,55 @@ func main() {
 	}
 }
 func onStart() {
-	config := global.GetGlobalConfig()
-	ImageRepository := os.Getenv("IMAGE_REPOSITORY")
-	if ImageRepository == "" {
-		setupLog.Error(errors.New("Not set env IMAGE_REPOSITORY"), "")
-		//os.Exit(1)
-	} else {
-		setupLog.Info("Get user set env value ", "IMAGE_REPOSITORY", ImageRepository)
-	}
-	config.ImageRepository = ImageRepository
-
-	RequestCpu := os.Getenv("REQUEST_CPU")
-	if RequestCpu == "" {
-		setupLog.Info("Not set env REQUEST_CPU, Using default request CPU [50m]")
-		RequestCpu = "50m"
-	} else {
-		setupLog.Info("Get user set env value", "REQUEST_CPU", RequestCpu)
-	}
-	config.RequestCpu = RequestCpu
-
-	LimitCpu := os.Getenv("LIMIT_CPU")
-	if LimitCpu == "" {
-		setupLog.Info("Not set env LIMIT_CPU, Using default limit CPU [unlimited]")
-	} else {
-		setupLog.Info("Get user set env value ", "LIMIT_CPU", LimitCpu)
-	}
-	config.LimitCpu = LimitCpu
-
-	RequestMemory := os.Getenv("REQUEST_MEMORY")
-	if RequestMemory == "" {
-		setupLog.Info("Not set env REQUEST_MEMORY, Using default request Memory [2Gi]")
-		RequestMemory = "2Gi"
-	} else {
-		setupLog.Info("Get user set env value", "REQUEST_MEMORY", RequestMemory)
-	}
-	config.RequestMemory = RequestMemory
-
-	LimitMemory := os.Getenv("LIMIT_MEMORY")
-	if LimitMemory == "" {
-		setupLog.Info("Not set env LIMIT_MEMORY, Using default limit Memory [2Gi]")
-		LimitMemory = "2Gi"
-	} else {
-		setupLog.Info("Get user set env value ", "LIMIT_MEMORY", LimitMemory)
-	}
-	config.LimitMemory = LimitMemory
-
-	ReadinessPath := os.Getenv("READINESS_PATH")
-	if ReadinessPath == "" {
-		setupLog.Info("Not set env READINESS_PATH, Using default spring boot 2 readiness path  [/actuator/health]")
-		ReadinessPath = "/actuator/health"
-	} else {
-		setupLog.Info("Get user set env value ", "READINESS_PATH", ReadinessPath)
-	}
-	config.ReadinessPath = ReadinessPath
-
-	ShutdownPath := os.Getenv("SHUTDOWN_PATH")
-	if ShutdownPath == "" {
-		ShutdownPath = "/spring/shutdown"
-		setupLog.Info("Not set env SHUTDOWN_PATH, Using default spring boot 2 shutdown path [" + ShutdownPath + "]")
-	} else {
-		setupLog.Info("Get user set env value ", "SHUTDOWN_PATH", ShutdownPath)
-	}
-	config.ShutdownPath = ShutdownPath
-
-	LivenessPath := os.Getenv("LIVENESS_PATH")
-	if LivenessPath == "" {
-		LivenessPath = "/actuator/health"
-		setupLog.Info("Not set env LIVENESS_PATH, Using default spring boot 2 liveness path [" + LivenessPath + "]")
-	} else {
-		setupLog.Info("Get user set env value ", "LIVENESS_PATH", LivenessPath)
-	}
-	config.LivenessPath = LivenessPath
-
-	Replicas := os.Getenv("REPLICAS")
-	if Replicas == "" {
-		Replicas = "3"
-		setupLog.Info("Not set env REPLICAS, Using default replicas [3]")
-	} else {
-		setupLog.Info("Get user set env value ", "REPLICAS", Replicas)
-	}
-	atoi, err := strconv.Atoi(Replicas)
+	config, err := global.LoadEnv()
 	if err != nil {
-		setupLog.Error(err, "Replicas is not a number")
+		setupLog.Error(err, "invalid operator configuration")
 		os.Exit(1)
 	}
-	config.Replicas = int32(atoi)
-
-	HostLogPath := os.Getenv("HOST_LOG_PATH")
-	if HostLogPath == "" {
-		HostLogPath = "/var/applog"
-		setupLog.Info("Not set env LIVENESS_PATH, Using default spring boot 2 host log  path [" + HostLogPath + "]")
-	} else {
-		setupLog.Info("Get user set env value ", "HOST_LOG_PATH", HostLogPath)
-	}
-	config.HostLogPath = HostLogPath
-
-	imagePullSecrets := os.Getenv("IMAGE_PULL_SECRETS")
-	if imagePullSecrets == "" {
-		setupLog.Info("Not set env IMAGE_PULL_SECRETS")
-	} else {
-		setupLog.Info("Get user set env value ", "IMAGE_PULL_SECRETS", imagePullSecrets)
-		config.ImagePullSecrets = strings.Split(imagePullSecrets, ",")
+	if config.ImageRepository == "" {
+		setupLog.Error(errors.New("Not set env IMAGE_REPOSITORY"), "")
 	}
+	logGlobalConfig()
+}
 
-	Env := os.Getenv("SPRING_BOOT_ENV")
-	if Env == "" {
-		setupLog.Info("Not set env Env")
-	} else {
-		config.Env = make(map[string]string)
-		setupLog.Info("Get user set env value ", "Env", Env)
-		for _, kv := range strings.Split(Env, ";") {
-			kyarray := strings.Split(kv, "=")
-			if len(kyarray) == 2 {
-				config.Env[kyarray[0]] = kyarray[1]
-			} else {
-				config.Env[kyarray[0]] = ""
-			}
+// loadConfigMap overrides the environment configuration with the operator ConfigMap before the manager starts
+func loadConfigMap(reader client.Reader, key types.NamespacedName) {
+	configMap := &v1.ConfigMap{}
+	if err := reader.Get(context.Background(), key, configMap); err != nil {
+		if apierrors.IsNotFound(err) {
+			setupLog.Info("Not found config map " + key.String() + ", using the env configuration")
+			return
 		}
+		setupLog.Error(err, "unable to read config map "+key.String())
+		os.Exit(1)
 	}
-
-	port := os.Getenv("SPRING_BOOT_DEFAULT_PORT")
-	if port == "" {
-		setupLog.Info("Not set env SPRING_BOOT_DEFAULT_PORT,using 8080 by default")
-		port = "8080"
-	} else {
-		setupLog.Info("Get user set env value ", "SPRING_BOOT_DEFAULT_PORT", port)
-	}
-	if i, err := strconv.Atoi(port); err == nil {
-		config.Port = int32(i)
-	} else {
-		setupLog.Info("Not parse set env port [" + port + "],using 8080 by default")
-		config.Port = int32(8080)
+	data, ok := configMap.Data[global.ConfigMapKey]
+	if !ok {
+		setupLog.Info("Not found key " + global.ConfigMapKey + " in config map " + key.String() + ", using the env configuration")
+		return
 	}
-
-	NodeAffinityKey := os.Getenv("NODE_AFFINITY_KEY")
-	if NodeAffinityKey == "" {
-		setupLog.Info("Not set env NODE_AFFINITY_KEY")
-	} else {
-		setupLog.Info("Get user set env value ", "NODE_AFFINITY_KEY", NodeAffinityKey)
+	if _, err := global.Load([]byte(data)); err != nil {
+		setupLog.Error(err, "invalid config map "+key.String())
+		os.Exit(1)
 	}
-	config.NodeAffinityKey = NodeAffinityKey
+	logGlobalConfig()
+}
 
-	NodeAffinityValues := os.Getenv("NODE_AFFINITY_VALUES")
-	if NodeAffinityValues == "" {
-		setupLog.Info("Not set env NODE_AFFINITY_VALUES")
+func logGlobalConfig() {
+	if marshal, err := json.Marshal(global.GetGlobalConfig()); err != nil {
+		setupLog.Error(err, "unable to marshal the global config")
 	} else {
-		setupLog.Info("Get user set env value ", "NODE_AFFINITY_VALUES", NodeAffinityValues)
+		setupLog.Info("Global config " + string(marshal))
 	}
-	config.NodeAffinityKey = NodeAffinityValues
+}
 
-	NodeAffinityOperator := os.Getenv("NODE_AFFINITY_OPERATOR")
-	if NodeAffinityOperator == "" {
-		setupLog.Info("Not set env NODE_AFFINITY_OPERATOR")
-	} else {
-		setupLog.Info("Get user set env value ", "NODE_AFFINITY_OPERATOR", NodeAffinityOperator)
+// operatorNamespace returns the namespace the operator runs in
+func operatorNamespace() string {
+	if namespace := os.Getenv("POD_NAMESPACE"); namespace != "" {
+		return namespace
 	}
-	config.NodeAffinityOperator = NodeAffinityOperator
-
-	if marshal, err := json.Marshal(config); err != nil {
-		setupLog.Error(err, "Replicas is not a number")
-		os.Exit(1)
-	} else {
-		setupLog.Info("Global config " + string(marshal))
+	if namespace, err := ioutil.ReadFile("/var/run/secrets/kubernetes.io/serviceaccount/namespace"); err == nil {
+		return strings.TrimSpace(string(namespace))
 	}
-
+	return "spring-boot-operator-system"
 }